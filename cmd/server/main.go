@@ -16,26 +16,25 @@ func main() {
 
 	mux := http.NewServeMux()
 
-	handleChampionRates(mux)
-	handleLobby(mux)
+	dataURL := "https://cdn.merakianalytics.com/riot/lol/resources/latest/en-US/championrates.json"
+	championRatesService := service.NewChampionRatesService(dataURL)
+
+	handleChampionRates(mux, championRatesService)
+	handleLobby(mux, championRatesService)
 
 	fmt.Println("Server starting on :8080")
 	handlerMiddleware := middleware.CorsMiddleware(mux)
 	log.Fatal(http.ListenAndServe(":8080", handlerMiddleware))
 }
 
-func handleChampionRates(mux *http.ServeMux) {
-	dataURL := "https://cdn.merakianalytics.com/riot/lol/resources/latest/en-US/championrates.json"
-
-	championRatesService := service.NewChampionRatesService(dataURL)
-
+func handleChampionRates(mux *http.ServeMux, championRatesService *service.ChampionRatesService) {
 	championRatesHandler := handler.NewChampionRatesHandler(championRatesService)
 
 	mux.HandleFunc("/proxy/championrates", championRatesHandler.HandleChampionRates)
 }
 
-func handleLobby(mux *http.ServeMux) {
-	lobbyService := service.NewLobbyService()
+func handleLobby(mux *http.ServeMux, championRatesService *service.ChampionRatesService) {
+	lobbyService := service.NewLobbyService(championRatesService)
 
 	lobbyHandler := handler.NewLobbyHandler(lobbyService)
 
@@ -57,16 +56,22 @@ func handleLobby(mux *http.ServeMux) {
 			return
 		}
 
-		lobbyResponse := lobbyService.CreateLobby(
+		lobbyResponse, err := lobbyService.CreateLobby(
 			lobbyRequest.Options,
 			lobbyRequest.BlueTeamName,
 			lobbyRequest.RedTeamName,
 			lobbyRequest.Champions,
 			lobbyRequest.DisabledChampionIds,
 		)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		json.NewEncoder(w).Encode(lobbyResponse)
 	})
 
+	mux.HandleFunc("/api/lobby/", lobbyHandler.HandleLobbyHistory)
 	mux.HandleFunc("/ws/lobby/", lobbyHandler.HandleLobbyWebSocket)
+	mux.HandleFunc("/ws/replay/", lobbyHandler.HandleReplayWebSocket)
 }
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+const resyncBacklog = 64
+
+type versionedUpdate struct {
+	version int64
+	message []byte
+}
+
+// audienceFeed is the versioned stream of states one audience of a lobby
+// receives. Blue, red and spectators each see their own projection of the
+// state, so each of them has its own version sequence.
+type audienceFeed struct {
+	version   int64
+	state     any
+	stateJSON []byte
+	updates   []versionedUpdate
+}
+
+type delayedState struct {
+	due       time.Time
+	stateJSON []byte
+}
+
+// lobbyBroadcaster sends every state change of a lobby as a PATCH against the
+// previous state of each audience. Full snapshots are only sent on join or
+// when a resync can no longer be served from the recent updates. With a
+// spectator delay, the spectator feed trails behind by holding its states
+// back until they are due.
+type lobbyBroadcaster struct {
+	mutex      sync.Mutex
+	lobby      *types.Lobby
+	feeds      map[types.LobbyRole]*audienceFeed
+	delayed    []delayedState
+	delayTimer *time.Timer
+}
+
+// newLobbyBroadcaster starts from the state the lobby was created with. It is
+// created when the first user joins, before any event could change it.
+func newLobbyBroadcaster(lobby *types.Lobby) *lobbyBroadcaster {
+	broadcaster := &lobbyBroadcaster{
+		lobby: lobby,
+		feeds: make(map[types.LobbyRole]*audienceFeed),
+	}
+	for _, audience := range audiences {
+		feed := &audienceFeed{}
+		stateJSON, err := json.Marshal(projectState(lobby.DraftState, audience))
+		if err == nil {
+			err = feed.update(stateJSON)
+		}
+		if err != nil {
+			log.Printf("Error marshaling draft state: %v", err)
+		}
+		broadcaster.feeds[audience] = feed
+	}
+	return broadcaster
+}
+
+func (b *lobbyBroadcaster) Broadcast(lobby *types.Lobby) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delay := time.Duration(lobby.DraftState.Options.SpectatorDelay) * time.Second
+
+	messages := make(map[types.LobbyRole][]byte)
+	for _, audience := range audiences {
+		state := projectState(lobby.DraftState, audience)
+		isDelayed := audience == types.RoleSpectator && delay > 0
+		if isDelayed && state.Deadline != 0 {
+			// Spectators see the turn start late, so its deadline moves with it
+			state.Deadline += delay.Milliseconds()
+		}
+
+		stateJSON, err := json.Marshal(state)
+		if err != nil {
+			log.Printf("Error marshaling draft state: %v", err)
+			continue
+		}
+
+		if isDelayed {
+			b.delay(stateJSON, delay)
+			continue
+		}
+
+		message, err := b.feeds[audience].advance(stateJSON)
+		if err != nil {
+			log.Printf("Error marshaling draft update: %v", err)
+			continue
+		}
+		messages[audience] = message
+	}
+
+	for _, user := range lobby.GetUsers() {
+		message := messages[user.Role]
+		if message == nil {
+			continue
+		}
+		err := user.Send(message)
+		if err != nil {
+			log.Printf("Error sending draft update to user %s: %v", user.ID, err)
+			lobby.RemoveUser(user.ID)
+		}
+	}
+}
+
+// Join adds the user to the lobby and sends it the current snapshot while no
+// broadcast can run, so its first update always builds on that snapshot.
+func (b *lobbyBroadcaster) Join(user *types.User) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lobby.AddUser(user)
+	b.feeds[user.Role].sendSnapshot(user)
+}
+
+// Resync brings a user that reports being at an older version up to date,
+// replaying the missed updates when they are still in the backlog.
+func (b *lobbyBroadcaster) Resync(user *types.User, version int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	feed := b.feeds[user.Role]
+	if version == feed.version {
+		return
+	}
+
+	if version < feed.version && len(feed.updates) > 0 && feed.updates[0].version <= version+1 {
+		for _, update := range feed.updates {
+			if update.version > version {
+				sendToUser(user, update.message)
+			}
+		}
+		return
+	}
+
+	feed.sendSnapshot(user)
+}
+
+// delay holds a spectator state back until it is due. States are always queued
+// with the same delay, so the queue stays ordered by due time.
+func (b *lobbyBroadcaster) delay(stateJSON []byte, delay time.Duration) {
+	b.delayed = append(b.delayed, delayedState{due: time.Now().Add(delay), stateJSON: stateJSON})
+	if b.delayTimer == nil {
+		b.delayTimer = time.AfterFunc(delay, b.flushDelayed)
+	}
+}
+
+func (b *lobbyBroadcaster) flushDelayed() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.delayTimer = nil
+	feed := b.feeds[types.RoleSpectator]
+
+	messages := [][]byte{}
+	now := time.Now()
+	for len(b.delayed) > 0 && !b.delayed[0].due.After(now) {
+		message, err := feed.advance(b.delayed[0].stateJSON)
+		if err != nil {
+			log.Printf("Error marshaling draft update: %v", err)
+		} else if message != nil {
+			messages = append(messages, message)
+		}
+		b.delayed = b.delayed[1:]
+	}
+
+	if len(b.delayed) > 0 {
+		b.delayTimer = time.AfterFunc(time.Until(b.delayed[0].due), b.flushDelayed)
+	}
+
+	for _, user := range b.lobby.GetUsersByRole(types.RoleSpectator) {
+		for _, message := range messages {
+			if err := user.Send(message); err != nil {
+				log.Printf("Error sending draft update to user %s: %v", user.ID, err)
+				b.lobby.RemoveUser(user.ID)
+				break
+			}
+		}
+	}
+}
+
+// advance moves the feed to the given state and returns the PATCH message that
+// describes the change, or nil if the audience sees no difference.
+func (f *audienceFeed) advance(stateJSON []byte) ([]byte, error) {
+	previous := f.state
+	if err := f.update(stateJSON); err != nil {
+		return nil, err
+	}
+
+	ops := diffJSON("", previous, f.state, nil)
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	f.version++
+	message, err := json.Marshal(types.PatchMessage{
+		Type:        types.MessagePatch,
+		Version:     f.version,
+		BaseVersion: f.version - 1,
+		ServerTime:  time.Now().UnixMilli(),
+		Ops:         ops,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f.updates = append(f.updates, versionedUpdate{version: f.version, message: message})
+	if len(f.updates) > resyncBacklog {
+		f.updates = f.updates[len(f.updates)-resyncBacklog:]
+	}
+
+	return message, nil
+}
+
+func (f *audienceFeed) update(stateJSON []byte) error {
+	var state any
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return err
+	}
+	f.state = state
+	f.stateJSON = stateJSON
+
+	return nil
+}
+
+func (f *audienceFeed) sendSnapshot(user *types.User) {
+	snapshotJSON, err := json.Marshal(types.SnapshotMessage{
+		Type:       types.MessageSnapshot,
+		Version:    f.version,
+		ServerTime: time.Now().UnixMilli(),
+		State:      f.stateJSON,
+	})
+	if err != nil {
+		log.Printf("Error marshaling draft state: %v", err)
+		return
+	}
+	sendToUser(user, snapshotJSON)
+}
+
+func sendToUser(user *types.User, message []byte) {
+	if err := user.Send(message); err != nil {
+		log.Printf("Error sending draft state to user %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+// diffJSON appends the JSON Patch (RFC 6902) operations that turn the decoded
+// document previous into next. Arrays that were only trimmed or appended to
+// are patched at their ends, arrays of equal length element by element, and
+// any other array change replaces the whole array.
+func diffJSON(path string, previous any, next any, ops []types.PatchOperation) []types.PatchOperation {
+	switch nextValue := next.(type) {
+	case map[string]any:
+		previousValue, ok := previous.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range slices.Sorted(maps.Keys(previousValue)) {
+			if _, exists := nextValue[key]; !exists {
+				ops = append(ops, types.PatchOperation{Op: "remove", Path: path + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(nextValue)) {
+			childPath := path + "/" + escapePointer(key)
+			if previousChild, exists := previousValue[key]; exists {
+				ops = diffJSON(childPath, previousChild, nextValue[key], ops)
+			} else {
+				ops = append(ops, types.PatchOperation{Op: "add", Path: childPath, Value: mustMarshal(nextValue[key])})
+			}
+		}
+		return ops
+	case []any:
+		previousValue, ok := previous.([]any)
+		if !ok {
+			break
+		}
+		if shiftedOps, shifted := diffShiftedArray(path, previousValue, nextValue, ops); shifted {
+			return shiftedOps
+		}
+		if len(previousValue) != len(nextValue) {
+			break
+		}
+		for i := range nextValue {
+			ops = diffJSON(path+"/"+strconv.Itoa(i), previousValue[i], nextValue[i], ops)
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(previous, next) {
+		ops = append(ops, types.PatchOperation{Op: "replace", Path: path, Value: mustMarshal(next)})
+	}
+	return ops
+}
+
+// diffShiftedArray recognizes arrays that only lost elements at either end
+// and gained new ones at the end, like a bounded log, so that they are not
+// rewritten element by element.
+func diffShiftedArray(path string, previous []any, next []any, ops []types.PatchOperation) ([]types.PatchOperation, bool) {
+	if len(previous) == len(next) && (len(next) == 0 || reflect.DeepEqual(previous[0], next[0])) {
+		return ops, false
+	}
+
+	if len(next) < len(previous) && reflect.DeepEqual(previous[:len(next)], next) {
+		for i := len(previous) - 1; i >= len(next); i-- {
+			ops = append(ops, types.PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		return ops, true
+	}
+
+	for dropped := 0; dropped < len(previous) || dropped == 0; dropped++ {
+		kept := len(previous) - dropped
+		if kept > len(next) || !reflect.DeepEqual(previous[dropped:], next[:kept]) {
+			continue
+		}
+		for range dropped {
+			ops = append(ops, types.PatchOperation{Op: "remove", Path: path + "/0"})
+		}
+		for _, value := range next[kept:] {
+			ops = append(ops, types.PatchOperation{Op: "add", Path: path + "/-", Value: mustMarshal(value)})
+		}
+		return ops, true
+	}
+
+	return ops, false
+}
+
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func mustMarshal(value any) json.RawMessage {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		// Values come from decoding valid JSON, so they always marshal back
+		panic(err)
+	}
+	return valueJSON
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func TestDiffJSON(t *testing.T) {
+	champion := &types.DraftChampion{ID: "ahri", Name: "Ahri", Status: types.ChampStatusSelected}
+	chat := func(texts ...string) []types.ChatMessage {
+		messages := []types.ChatMessage{}
+		for _, text := range texts {
+			messages = append(messages, types.ChatMessage{Role: types.RoleBlueTeam, Channel: types.ChatAll, Text: text})
+		}
+		return messages
+	}
+	entry := func(step int) types.HistoryEntry {
+		return types.HistoryEntry{Step: step, Side: types.TurnBlue, Action: types.ActionPick, ChampionID: "ahri"}
+	}
+
+	tests := []struct {
+		name     string
+		previous any
+		next     any
+		maxOps   int
+	}{
+		{
+			name:     "chat trimmed at the head",
+			previous: types.DraftState{Chat: chat("a", "b", "c")},
+			next:     types.DraftState{Chat: chat("b", "c", "d")},
+			maxOps:   2,
+		},
+		{
+			name:     "history appended",
+			previous: types.DraftState{History: []types.HistoryEntry{entry(1)}},
+			next:     types.DraftState{History: []types.HistoryEntry{entry(1), entry(2)}},
+			maxOps:   1,
+		},
+		{
+			name:     "history appended to an empty array",
+			previous: types.DraftState{History: []types.HistoryEntry{}},
+			next:     types.DraftState{History: []types.HistoryEntry{entry(1)}},
+			maxOps:   1,
+		},
+		{
+			name:     "undo nulls slot 0",
+			previous: types.TeamState{Picks: []*types.DraftChampion{champion, nil, nil, nil, nil}},
+			next:     types.TeamState{Picks: make([]*types.DraftChampion, 5)},
+			maxOps:   2,
+		},
+		{
+			name:     "slot 0 changes with the rest in place",
+			previous: types.TeamState{Picks: []*types.DraftChampion{nil, champion, nil, nil, nil}},
+			next:     types.TeamState{Picks: []*types.DraftChampion{champion, champion, nil, nil, nil}},
+			maxOps:   1,
+		},
+		{
+			name:     "array only got shorter",
+			previous: types.DraftState{History: []types.HistoryEntry{entry(1), entry(2), entry(3)}},
+			next:     types.DraftState{History: []types.HistoryEntry{entry(1)}},
+			maxOps:   2,
+		},
+		{
+			name:     "array emptied",
+			previous: types.DraftState{History: []types.HistoryEntry{entry(1), entry(2)}},
+			next:     types.DraftState{History: []types.HistoryEntry{}},
+			maxOps:   2,
+		},
+		{
+			name:     "omitempty fields appear",
+			previous: types.DraftState{},
+			next: types.DraftState{
+				Deadline:    1000,
+				PendingUndo: types.TurnBlue,
+				Pause:       &types.PauseState{By: types.TurnRed, Since: time.Unix(0, 0).UTC()},
+			},
+			maxOps: 3,
+		},
+		{
+			name: "omitempty fields disappear",
+			previous: types.DraftState{
+				Deadline:    1000,
+				PendingUndo: types.TurnBlue,
+				Pause:       &types.PauseState{By: types.TurnRed, Since: time.Unix(0, 0).UTC()},
+			},
+			next:   types.DraftState{},
+			maxOps: 3,
+		},
+		{
+			name:     "keys that need escaping",
+			previous: map[string]any{"a/b": 1, "c~d": []int{1}},
+			next:     map[string]any{"a/b": 2, "c~d": []int{1, 2}},
+			maxOps:   2,
+		},
+		{
+			name:     "unchanged document",
+			previous: types.DraftState{Chat: chat("a")},
+			next:     types.DraftState{Chat: chat("a")},
+			maxOps:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previous := decodeJSON(t, test.previous)
+			next := decodeJSON(t, test.next)
+
+			ops := diffJSON("", previous, next, nil)
+			if len(ops) > test.maxOps {
+				t.Errorf("expected at most %d ops, got %d: %+v", test.maxOps, len(ops), ops)
+			}
+
+			patched := applyPatch(t, decodeJSON(t, test.previous), ops)
+			if !reflect.DeepEqual(patched, next) {
+				t.Errorf("patched document differs\n got: %v\nwant: %v\n ops: %+v", patched, next, ops)
+			}
+		})
+	}
+}
+
+func decodeJSON(t *testing.T, value any) any {
+	t.Helper()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(valueJSON, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return decoded
+}
+
+func applyPatch(t *testing.T, document any, ops []types.PatchOperation) any {
+	t.Helper()
+
+	for _, op := range ops {
+		var value any
+		if op.Value != nil {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				t.Fatalf("unmarshal value of %s %s: %v", op.Op, op.Path, err)
+			}
+		}
+
+		tokens := []string{}
+		if op.Path != "" {
+			for _, token := range strings.Split(op.Path, "/")[1:] {
+				tokens = append(tokens, strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~"))
+			}
+		}
+		document = applyOperation(t, document, tokens, op, value)
+	}
+	return document
+}
+
+func applyOperation(t *testing.T, node any, tokens []string, op types.PatchOperation, value any) any {
+	t.Helper()
+
+	if len(tokens) == 0 {
+		if op.Op != "replace" && op.Op != "add" {
+			t.Fatalf("cannot %s the whole document", op.Op)
+		}
+		return value
+	}
+
+	token := tokens[0]
+	switch current := node.(type) {
+	case map[string]any:
+		if len(tokens) > 1 {
+			current[token] = applyOperation(t, current[token], tokens[1:], op, value)
+			return current
+		}
+		switch op.Op {
+		case "add":
+			current[token] = value
+		case "replace":
+			if _, exists := current[token]; !exists {
+				t.Fatalf("replace of missing member %s", op.Path)
+			}
+			current[token] = value
+		case "remove":
+			if _, exists := current[token]; !exists {
+				t.Fatalf("remove of missing member %s", op.Path)
+			}
+			delete(current, token)
+		default:
+			t.Fatalf("unknown op %s", op.Op)
+		}
+		return current
+
+	case []any:
+		if len(tokens) == 1 && op.Op == "add" && token == "-" {
+			return append(current, value)
+		}
+
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index > len(current) || (index == len(current) && op.Op != "add") {
+			t.Fatalf("invalid array index in %s", op.Path)
+		}
+		if len(tokens) > 1 {
+			current[index] = applyOperation(t, current[index], tokens[1:], op, value)
+			return current
+		}
+		switch op.Op {
+		case "add":
+			return append(current[:index], append([]any{value}, current[index:]...)...)
+		case "replace":
+			current[index] = value
+		case "remove":
+			return append(current[:index], current[index+1:]...)
+		default:
+			t.Fatalf("unknown op %s", op.Op)
+		}
+		return current
+	}
+
+	t.Fatalf("path %s does not exist", op.Path)
+	return nil
+}
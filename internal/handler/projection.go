@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fearlessdraft-server/pkg/types"
+)
+
+var audiences = []types.LobbyRole{types.RoleBlueTeam, types.RoleRedTeam, types.RoleSpectator}
+
+// projectState returns the draft state as the given audience is allowed to see
+// it: hovers and team chat only reach the team they belong to, unless the lobby
+// lets spectators see everything.
+func projectState(state types.DraftState, audience types.LobbyRole) types.DraftState {
+	if audience == types.RoleSpectator && state.Options.SpectatorsSeeAll {
+		return state
+	}
+
+	if audience != types.RoleBlueTeam {
+		state.BlueTeam = hideHovers(state.BlueTeam)
+	}
+	if audience != types.RoleRedTeam {
+		state.RedTeam = hideHovers(state.RedTeam)
+	}
+
+	team := ""
+	switch audience {
+	case types.RoleBlueTeam:
+		team = state.BlueTeam.Name
+	case types.RoleRedTeam:
+		team = state.RedTeam.Name
+	}
+
+	chat := []types.ChatMessage{}
+	for _, message := range state.Chat {
+		if message.Channel == types.ChatAll || (audience != types.RoleSpectator && message.Team == team) {
+			chat = append(chat, message)
+		}
+	}
+	state.Chat = chat
+
+	return state
+}
+
+func hideHovers(team types.TeamState) types.TeamState {
+	team.Picks = withoutHovers(team.Picks)
+	team.Bans = withoutHovers(team.Bans)
+	return team
+}
+
+func withoutHovers(slots []*types.DraftChampion) []*types.DraftChampion {
+	visible := make([]*types.DraftChampion, len(slots))
+	for i, champion := range slots {
+		if champion != nil && champion.Status != types.ChampStatusHover {
+			visible[i] = champion
+		}
+	}
+	return visible
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"testing"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func TestProjectStateTeamChatFollowsTeamAcrossSides(t *testing.T) {
+	// Team A wrote on blue in game 1 and plays on red after the side swap
+	state := types.DraftState{
+		BlueTeam: types.TeamState{Name: "Team B"},
+		RedTeam:  types.TeamState{Name: "Team A"},
+		Chat: []types.ChatMessage{
+			{Role: types.RoleBlueTeam, Team: "Team A", Channel: types.ChatTeam, Text: "plan"},
+			{Role: types.RoleBlueTeam, Team: "Team A", Channel: types.ChatAll, Text: "glhf"},
+		},
+	}
+
+	visible := map[types.LobbyRole]int{
+		types.RoleBlueTeam:  1,
+		types.RoleRedTeam:   2,
+		types.RoleSpectator: 1,
+	}
+	for audience, want := range visible {
+		if got := len(projectState(state, audience).Chat); got != want {
+			t.Errorf("%s sees %d messages, want %d", audience, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fearlessdraft-server/internal/service"
+	"fearlessdraft-server/pkg/types"
+
+	"github.com/gorilla/websocket"
+)
+
+const maxReplaySpeed = 100
+
+type LobbyHistoryResponse struct {
+	LobbyID string             `json:"lobbyId"`
+	Seed    int64              `json:"seed"`
+	Options types.DraftOptions `json:"options"`
+	Games   []types.GameRecord `json:"games"`
+}
+
+func (h *LobbyHandler) HandleLobbyHistory(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[4] != "history" {
+		http.Error(w, "Invalid lobby URL", http.StatusBadRequest)
+		return
+	}
+
+	lobby, exists := h.lobbyService.GetLobby(parts[3])
+	if !exists {
+		http.Error(w, "Lobby not found", http.StatusNotFound)
+		return
+	}
+
+	historyJSON, err := json.Marshal(LobbyHistoryResponse{
+		LobbyID: lobby.ID,
+		Seed:    lobby.Seed,
+		Options: lobby.DraftState.Options,
+		Games:   service.DelayedSeries(lobby, lobby.DraftService.Series()),
+	})
+	if err != nil {
+		http.Error(w, "Failed to generate JSON", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(historyJSON)
+}
+
+func (h *LobbyHandler) HandleReplayWebSocket(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 4 {
+		http.Error(w, "Invalid replay URL", http.StatusBadRequest)
+		return
+	}
+
+	lobby, exists := h.lobbyService.GetLobby(parts[3])
+	if !exists {
+		http.Error(w, "Lobby not found", http.StatusNotFound)
+		return
+	}
+
+	speed := 1.0
+	if speedStr := r.URL.Query().Get("speed"); speedStr != "" {
+		parsed, err := strconv.ParseFloat(speedStr, 64)
+		if err != nil || parsed <= 0 || parsed > maxReplaySpeed {
+			http.Error(w, "Invalid replay speed", http.StatusBadRequest)
+			return
+		}
+		speed = parsed
+	}
+
+	games := service.DelayedSeries(lobby, lobby.DraftService.Series())
+	if gameStr := r.URL.Query().Get("game"); gameStr != "" {
+		game, err := strconv.Atoi(gameStr)
+		if err != nil || game < 1 || game > len(games) {
+			http.Error(w, "Invalid game", http.StatusBadRequest)
+			return
+		}
+		games = games[game-1 : game]
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Stop streaming as soon as the viewer disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for i, frame := range service.BuildReplay(lobby, games, speed) {
+		select {
+		case <-time.After(frame.Delay):
+		case <-closed:
+			return
+		}
+
+		stateJSON, err := json.Marshal(frame.State)
+		if err != nil {
+			log.Printf("Error marshaling replay state: %v", err)
+			return
+		}
+		snapshotJSON, err := json.Marshal(types.SnapshotMessage{
+			Type:       types.MessageSnapshot,
+			Version:    int64(i + 1),
+			ServerTime: time.Now().UnixMilli(),
+			State:      stateJSON,
+		})
+		if err != nil {
+			log.Printf("Error marshaling replay state: %v", err)
+			return
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, snapshotJSON); err != nil {
+			log.Printf("Error sending replay state: %v", err)
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
 	"fearlessdraft-server/internal/service"
 	"fearlessdraft-server/pkg/types"
@@ -13,6 +14,8 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const maxUsernameLength = 32
+
 type LobbyHandler struct {
 	lobbyService *service.LobbyService
 	upgrader     websocket.Upgrader
@@ -65,23 +68,11 @@ func (h *LobbyHandler) HandleLobbyWebSocket(w http.ResponseWriter, r *http.Reque
 	}
 	defer conn.Close()
 
-	User := &types.User{
-		ID:   generateUserID(),
-		Conn: conn,
-		Role: role,
-	}
-
-	lobby.AddUser(User)
+	User := types.NewUser(generateUserID(), conn, role, sanitizeUsername(r.URL.Query().Get("name")))
 
-	draftStateJSON, err := json.Marshal(lobby.DraftState)
-	if err != nil {
-		log.Printf("Error marshaling draft state: %v", err)
-		return
-	}
-	err = User.Conn.WriteMessage(websocket.TextMessage, draftStateJSON)
-	if err != nil {
-		log.Printf("Error sending draft state to user %s: %v", User.ID, err)
-		lobby.RemoveUser(User.ID)
+	h.getBroadcaster(lobby).Join(User)
+	if role != types.RoleSpectator {
+		lobby.DraftService.CaptainJoined(h.sendDraftState)
 	}
 
 	h.handleUserConnection(lobby, User)
@@ -107,43 +98,72 @@ func (h *LobbyHandler) processMessage(lobby *types.Lobby, User *types.User, mess
 		return
 	}
 
+	if event.Type == types.Resync {
+		h.getBroadcaster(lobby).Resync(User, event.Version)
+		return
+	}
+
+	if User.Role == types.RoleSpectator && event.Type != types.Message {
+		h.sendError(User, "spectators cannot take draft actions")
+		return
+	}
+
 	if (User.Role == types.RoleBlueTeam && event.User != types.TurnBlue) ||
 		(User.Role == types.RoleRedTeam && event.User != types.TurnRed) {
 		log.Println("Not your turn")
+		h.sendError(User, "not your turn")
 		return
 	}
 
-	if lobby.DraftService == nil {
-		lobby.DraftService = service.NewDraftService(lobby)
-	}
+	event.Sender = User
 
-	success, err := lobby.DraftService.HandleEvent(&event, h.sendDraftState)
+	_, err := lobby.DraftService.HandleEvent(&event, h.sendDraftState)
 	if err != nil {
 		log.Printf("Error processing draft event: %v", err)
-	}
-	if success {
-		h.sendDraftState(lobby)
+		h.sendError(User, err.Error())
 	}
 }
 
 func (h *LobbyHandler) sendDraftState(lobby *types.Lobby) {
-	draftStateJSON, err := json.Marshal(lobby.DraftState)
+	h.getBroadcaster(lobby).Broadcast(lobby)
+}
+
+func (h *LobbyHandler) getBroadcaster(lobby *types.Lobby) types.BroadcasterInterface {
+	lobby.Mutex.Lock()
+	defer lobby.Mutex.Unlock()
+
+	if lobby.Broadcaster == nil {
+		lobby.Broadcaster = newLobbyBroadcaster(lobby)
+	}
+	return lobby.Broadcaster
+}
+
+func (h *LobbyHandler) sendError(User *types.User, reason string) {
+	errorJSON, err := json.Marshal(types.ErrorMessage{
+		Type:   types.MessageError,
+		Reason: reason,
+	})
 	if err != nil {
-		log.Printf("Error marshaling draft state: %v", err)
+		log.Printf("Error marshaling error message: %v", err)
 		return
 	}
-	for _, user := range lobby.Users {
-		err = user.Conn.WriteMessage(websocket.TextMessage, draftStateJSON)
-		if err != nil {
-			log.Printf("Error sending draft state to user %s: %v", user.ID, err)
-			lobby.RemoveUser(user.ID)
-
-		}
+	err = User.Send(errorJSON)
+	if err != nil {
+		log.Printf("Error sending error message to user %s: %v", User.ID, err)
 	}
 }
 
 func (h *LobbyHandler) removeUser(lobby *types.Lobby, User *types.User) {
 	lobby.RemoveUser(User.ID)
+	User.Close()
+}
+
+func sanitizeUsername(name string) string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxUsernameLength {
+		name = string([]rune(name)[:maxUsernameLength])
+	}
+	return name
 }
 
 func generateUserID() string {
@@ -6,12 +6,21 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	"fearlessdraft-server/pkg/types"
 )
 
+const championRatesTTL = 6 * time.Hour
+
 type ChampionRatesService struct {
 	dataURL string
+
+	cacheMutex sync.Mutex
+	cache      *types.RemappedChampionRates
+	cachedAt   time.Time
+	refreshing bool
 }
 
 func NewChampionRatesService(url string) *ChampionRatesService {
@@ -74,3 +83,36 @@ func (s *ChampionRatesService) FetchAndTransformRates() (*types.RemappedChampion
 
 	return remappedData, nil
 }
+
+// PlayRate looks the rate up in the cached data without blocking. A stale or
+// missing cache is refreshed in the background, and loaded is false until the
+// first fetch has succeeded.
+func (s *ChampionRatesService) PlayRate(championID string, role types.Role) (float64, bool) {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	if (s.cache == nil || time.Since(s.cachedAt) > championRatesTTL) && !s.refreshing {
+		s.refreshing = true
+		go s.refreshCache()
+	}
+
+	if s.cache == nil {
+		return 0, false
+	}
+	return s.cache.Data[championID][string(role)].PlayRate, true
+}
+
+func (s *ChampionRatesService) refreshCache() {
+	rates, err := s.FetchAndTransformRates()
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.refreshing = false
+	if err != nil {
+		log.Printf("Error refreshing champion rates: %v", err)
+		return
+	}
+	s.cache = rates
+	s.cachedAt = time.Now()
+}
@@ -2,7 +2,7 @@ package service
 
 import (
 	"fmt"
-	"log"
+	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -10,211 +10,412 @@ import (
 	"fearlessdraft-server/pkg/types"
 )
 
+// timeoutGrace gives clients whose clock runs slightly behind a chance to
+// lock in before the server resolves the turn on its own.
+const timeoutGrace = 2 * time.Second
+
 type DraftService struct {
-	lobby        *types.Lobby
-	turnCounter  int
-	timerMutex   sync.Mutex
-	timerStopper chan struct{}
+	lobby       *types.Lobby
+	turnCounter int
+	rng         *rand.Rand
+	playRates   PlayRateSource
+	mutex       sync.Mutex
+	timer       *time.Timer
+	deadline    time.Time
+	expiresAt   time.Time
+	chatTimes   map[string][]time.Time
+}
+
+type PlayRateSource interface {
+	PlayRate(championID string, role types.Role) (rate float64, loaded bool)
+}
+
+func NewDraftService(lobby *types.Lobby, playRates PlayRateSource) *DraftService {
+	return NewDraftServiceWithSource(lobby, playRates, rand.NewSource(lobby.Seed))
 }
 
-func NewDraftService(lobby *types.Lobby) *DraftService {
+// NewDraftServiceWithSource creates a draft service whose coin tosses and
+// automatic picks are drawn from the given source, so they can be reproduced.
+// Picks weighted by play rate (the role timeout policy, and the hover policy
+// when nothing is hovered) also depend on the rates loaded at that moment, so
+// only a fixed or nil play rate source makes them reproducible from the seed.
+// The history records every automatic pick either way.
+func NewDraftServiceWithSource(lobby *types.Lobby, playRates PlayRateSource, source rand.Source) *DraftService {
 	service := &DraftService{
 		lobby:       lobby,
 		turnCounter: 1,
+		rng:         rand.New(source),
+		playRates:   playRates,
+	}
+	service.resetChampionAvailability()
+
+	if lobby.DraftState.Game == 1 && lobby.DraftState.Options.CoinToss {
+		service.startSideSelection(service.tossCoin(), true)
+		service.resetTimer()
 	}
 
 	return service
 }
 
-func (ds *DraftService) StartTimer(sendStateFunc func(*types.Lobby)) {
-	if !ds.lobby.DraftState.HasTimer || ds.lobby.DraftState.Timer <= -2 {
+// startTimer and stopTimer must be called with ds.mutex held. The timer
+// callback takes the same lock and checks that it is still the current timer,
+// so a timeout can never act on a turn that was already resolved by an event.
+func (ds *DraftService) startTimer(sendStateFunc func(*types.Lobby)) {
+	ds.stopTimer()
+
+	state := &ds.lobby.DraftState
+	if !state.HasTimer || state.Pause != nil || !ds.hasClock() {
 		return
 	}
 
-	ds.timerMutex.Lock()
-	defer ds.timerMutex.Unlock()
+	ds.deadline = time.Now().Add(time.Duration(state.Timer) * time.Second)
+	ds.expiresAt = ds.deadline.Add(time.Duration(ds.timeBank()) * time.Second)
+	state.Deadline = ds.deadline.UnixMilli()
 
-	if ds.timerStopper != nil {
-		close(ds.timerStopper)
-	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(ds.expiresAt.Add(timeoutGrace)), func() {
+		ds.mutex.Lock()
+		defer ds.mutex.Unlock()
 
-	ds.timerStopper = make(chan struct{})
-	stopper := ds.timerStopper
+		if ds.timer != timer {
+			return
+		}
 
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
+		ds.handleTimeout(sendStateFunc)
+		sendStateFunc(ds.lobby)
+	})
+	ds.timer = timer
+}
 
-		for {
-			select {
-			case <-ticker.C:
-				ds.timerMutex.Lock()
-				if ds.lobby.DraftState.Timer >= -2 {
-					ds.lobby.DraftState.Timer--
-					sendStateFunc(ds.lobby)
-				}
+// stopTimer freezes the clock: whatever is left of the turn timer and of the
+// time bank of the team on turn is written back to the state.
+func (ds *DraftService) stopTimer() {
+	if ds.timer == nil {
+		return
+	}
+	ds.timer.Stop()
+	ds.timer = nil
 
-				if ds.lobby.DraftState.Timer < -2 {
-					ds.timerMutex.Unlock()
-					ds.handleTimeout(sendStateFunc)
-					return
-				}
-				ds.timerMutex.Unlock()
+	state := &ds.lobby.DraftState
+	state.Deadline = 0
 
-			case <-stopper:
-				return
-			}
-		}
-	}()
-}
+	remaining := time.Until(ds.deadline)
+	if remaining > 0 {
+		state.Timer = int(math.Ceil(remaining.Seconds()))
+		return
+	}
+	state.Timer = 0
 
-func (ds *DraftService) handleTimeout(sendStateFunc func(*types.Lobby)) {
-	teamKey := ds.determineTeamKey(ds.lobby.DraftState.Turn)
-	team := ds.getTeamState(teamKey)
-	isBanPhase := ds.lobby.DraftState.Phase == types.PhaseBan
+	if team := ds.timeBankTeam(); team != nil {
+		team.TimeBank = max(int(math.Ceil(time.Until(ds.expiresAt).Seconds())), 0)
+	}
+}
 
-	if isBanPhase {
-		randomChampionID := "none"
-		ds.updateStringArray(team.Bans, &randomChampionID)
-	} else {
-		champion := ds.isAnyChampionInHoverState(team)
-		if champion == nil {
-			champion = ds.getRandomChampions()
-			ds.updateChampionArray(team.Picks, champion)
-		} else {
-			champion.Status = types.ChampStatusSelected
-			ds.setChampionStatusToDisabled(champion.ID)
-		}
+func (ds *DraftService) hasClock() bool {
+	switch ds.lobby.DraftState.Phase {
+	case types.PhaseBan, types.PhasePick:
+		_, ok := ds.currentStep()
+		return ok
+	case types.PhaseTrade, types.PhaseSide:
+		return true
 	}
+	return false
+}
 
-	ds.turnCounter++
-	ds.updatePhaseAndTurn()
+// timeBankTeam returns the team whose time bank runs once the turn timer is
+// exhausted, or nil outside of bans and picks.
+func (ds *DraftService) timeBankTeam() *types.TeamState {
+	step, ok := ds.currentStep()
+	if !ok || ds.lobby.DraftState.Phase != step.Action.Phase() {
+		return nil
+	}
+	return ds.getTeamState(ds.determineTeamKey(step.Side))
+}
 
-	ds.lobby.DraftState.Timer = 30
-	sendStateFunc(ds.lobby)
-	ds.StartTimer(sendStateFunc)
+func (ds *DraftService) timeBank() int {
+	if team := ds.timeBankTeam(); team != nil {
+		return team.TimeBank
+	}
+	return 0
 }
 
-func (ds *DraftService) isAnyChampionInHoverState(teamState *types.TeamState) *types.DraftChampion {
-	for _, pick := range teamState.Picks {
-		if pick != nil && pick.Status == types.ChampStatusHover {
-			return pick
-		}
+func (ds *DraftService) resetTimer() {
+	switch ds.lobby.DraftState.Phase {
+	case types.PhaseTrade:
+		ds.lobby.DraftState.Timer = ds.lobby.DraftState.Options.TradeTimer
+		return
+	case types.PhaseSide:
+		ds.lobby.DraftState.Timer = ds.lobby.DraftState.Options.SideTimer
+		return
 	}
-	return nil
+
+	step, ok := ds.currentStep()
+	if !ok {
+		ds.lobby.DraftState.Timer = 0
+		return
+	}
+	ds.lobby.DraftState.Timer = ds.lobby.DraftState.Options.TurnDuration(step.Action)
 }
 
-func (ds *DraftService) getRandomChampions() *types.DraftChampion {
-	availableChampions := []*types.DraftChampion{}
+// handleTimeoutEvent lets either captain report an expired turn during the
+// grace window instead of waiting for the server timer. It is only accepted
+// once the turn timer and the time bank of the team on turn are both
+// exhausted, and then resolves the turn exactly like the server timer would.
+func (ds *DraftService) handleTimeoutEvent(sendStateFunc func(*types.Lobby)) (bool, error) {
+	state := &ds.lobby.DraftState
 
-	for _, champion := range ds.lobby.Champions {
-		if champion.Status != types.ChampStatusDisabled {
-			availableChampions = append(availableChampions, champion)
-		}
+	step, ok := ds.currentStep()
+	if !ok || state.Phase != step.Action.Phase() {
+		return false, fmt.Errorf("no pick or ban is in progress")
+	}
+	if !state.HasTimer {
+		return false, fmt.Errorf("this draft has no timer")
 	}
 
-	if len(availableChampions) == 0 {
-		log.Println("No available champions to select")
-		return nil
+	if ds.timer == nil || time.Now().Before(ds.expiresAt) {
+		return false, fmt.Errorf("the turn has not timed out yet")
 	}
 
-	source := rand.NewSource(time.Now().UnixNano())
-	rng := rand.New(source)
-	randomChampion := availableChampions[rng.Intn(len(availableChampions))]
+	ds.handleTimeout(sendStateFunc)
+
+	return true, nil
+}
+
+func (ds *DraftService) handleTimeout(sendStateFunc func(*types.Lobby)) {
+	switch ds.lobby.DraftState.Phase {
+	case types.PhaseTrade:
+		ds.finishTrade()
+		return
+	case types.PhaseSide:
+		// Sides stay as they are when the chooser runs out of time
+		ds.chooseSide(ds.lobby.DraftState.Turn)
+		return
+	}
 
-	selectedChampion := &types.DraftChampion{
-		ID:     randomChampion.ID,
-		Name:   randomChampion.Name,
-		Roles:  randomChampion.Roles,
-		Status: types.ChampStatusSelected,
+	step, ok := ds.currentStep()
+	if !ok {
+		return
 	}
 
-	randomChampion.Status = types.ChampStatusDisabled
+	ds.stopTimer()
+
+	championID := ds.resolveTimeout(step)
 
-	return selectedChampion
+	ds.completeStep(step, championID, true)
+
+	ds.resetTimer()
+	ds.startTimer(sendStateFunc)
 }
 
-func (ds *DraftService) StopTimer() {
-	ds.timerMutex.Lock()
-	defer ds.timerMutex.Unlock()
+// HandleEvent applies a client event and, if it changed the state, broadcasts
+// the new state before releasing the lock so that broadcasts from events and
+// from the timer can never interleave or go out of order.
+func (ds *DraftService) HandleEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
 
-	if ds.timerStopper != nil {
-		close(ds.timerStopper)
-		ds.timerStopper = nil
+	success, err := ds.dispatchEvent(event, sendStateFunc)
+	if success {
+		sendStateFunc(ds.lobby)
 	}
+	return success, err
 }
 
-func (ds *DraftService) HandleEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
-	if ds.lobby.DraftState.Turn != event.User &&
+func (ds *DraftService) dispatchEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	if !ds.canActOutOfTurn(event.Type) &&
+		ds.lobby.DraftState.Turn != event.User &&
 		ds.lobby.DraftState.Turn != types.TurnStart &&
 		ds.lobby.DraftState.Turn != types.TurnEnd {
-		return false, nil
+		return false, fmt.Errorf("not your turn")
 	}
 
-	if event.Type == types.Select {
-		ds.StopTimer()
+	if ds.lobby.DraftState.Pause != nil && ds.isBlockedWhilePaused(event.Type) {
+		return false, fmt.Errorf("the draft is paused")
 	}
 
 	switch event.Type {
 	case types.Start:
 		return ds.handleStartEvent(event, sendStateFunc)
 	case types.Hover:
-		if ds.lobby.DraftState.Phase == types.PhasePick {
-			return ds.handleHoverEvent(event)
-		}
-		return false, nil
+		return ds.handleHoverEvent(event)
 	case types.Select:
 		return ds.handleSelectEvent(event, sendStateFunc)
+	case types.Result:
+		return ds.handleResultEvent(event)
+	case types.ResultAccept:
+		return ds.handleResultAccept(event, sendStateFunc)
+	case types.ResultDecline:
+		return ds.handleResultDecline(event)
+	case types.UndoRequest:
+		return ds.handleUndoRequest(event)
+	case types.UndoAccept:
+		return ds.handleUndoAccept(event, sendStateFunc)
+	case types.UndoDecline:
+		return ds.handleUndoDecline(event)
+	case types.SideChoice:
+		return ds.handleSideEvent(event)
+	case types.Trade:
+		return ds.handleTradeEvent(event)
+	case types.Positions:
+		return ds.handlePositionsEvent(event)
+	case types.Pause:
+		return ds.handlePauseEvent(event)
+	case types.Resume:
+		return ds.handleResumeEvent(event, sendStateFunc)
 	case types.Timeout:
-		// TODO
-		ds.lobby.DraftState.Timer = 30
-		sendStateFunc(ds.lobby)
-		return true, nil
+		return ds.handleTimeoutEvent(sendStateFunc)
 	case types.Message:
-		// Currently not implemented
-		return true, nil
+		return ds.handleMessageEvent(event)
 	default:
 		return false, fmt.Errorf("unknown event type: %s", event.Type)
 	}
 }
 
+func (ds *DraftService) canActOutOfTurn(eventType types.EventType) bool {
+	switch eventType {
+	case types.UndoRequest, types.UndoAccept, types.UndoDecline, types.Pause, types.Resume, types.Timeout, types.Trade, types.Positions, types.Message,
+		types.Result, types.ResultAccept, types.ResultDecline:
+		return true
+	}
+	return false
+}
+
+func (ds *DraftService) isBlockedWhilePaused(eventType types.EventType) bool {
+	switch eventType {
+	case types.Hover, types.Select, types.Timeout, types.UndoRequest, types.UndoAccept, types.UndoDecline:
+		return true
+	}
+	return false
+}
+
 func (ds *DraftService) handleStartEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
 	switch ds.lobby.DraftState.Phase {
 	case types.PhaseReady:
 		ds.handleWaitingConfirm(event, types.TurnStart, func() {
-			ds.lobby.DraftState.Turn = types.TurnBlue
-			ds.lobby.DraftState.Phase = types.PhaseBan
-			ds.lobby.DraftState.Timer = 30
-			sendStateFunc(ds.lobby)
-			ds.StartTimer(sendStateFunc)
+			ds.updatePhaseAndTurn()
+			ds.resetTimer()
+			ds.startTimer(sendStateFunc)
 		})
+	case types.PhaseTrade:
+		ds.handleWaitingConfirm(event, types.TurnStart, ds.finishTrade)
 	case types.PhaseEnd:
-		if event.User == types.TurnBlue {
-			ds.lobby.DraftState.Turn = types.TurnRed
-		} else if event.User == types.TurnRed {
-			ds.lobby.DraftState.Turn = types.TurnBlue
+		// Ending a game without a result needs both captains, so that the
+		// losing side cannot skip a reported result on its own
+		if ds.lobby.DraftState.PendingResult != nil {
+			return false, fmt.Errorf("a game result is waiting for confirmation")
 		}
-		if ds.lobby.DraftState.Game < 5 {
-			ds.lobby.DraftState.Phase = types.PhaseRestart
-		} else {
-			ds.lobby.DraftState.Phase = types.PhaseOver
-		}
-	case types.PhaseRestart:
-		if ds.lobby.DraftState.Game < 5 {
-			ds.handleRestart(event.Flag)
+		ds.handleWaitingConfirm(event, types.TurnEnd, func() {
+			ds.finishGame("", ds.otherSide(event.User), sendStateFunc)
+		})
+	case types.PhaseSide:
+		// Older clients confirm the side choice with a START event and a swap flag
+		side := event.User
+		if event.Flag {
+			side = ds.otherSide(event.User)
 		}
+		ds.chooseSide(side)
+	}
+
+	return true, nil
+}
+
+// handleResultEvent records the winner reported by one captain. The result only
+// counts once the other captain accepts it, like an undo request.
+func (ds *DraftService) handleResultEvent(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.Phase != types.PhaseEnd {
+		return false, fmt.Errorf("game result can only be reported once the draft is over")
+	}
+	if event.Side != types.TurnBlue && event.Side != types.TurnRed {
+		return false, fmt.Errorf("invalid winner side: %s", event.Side)
+	}
+	if state.PendingResult != nil {
+		return false, fmt.Errorf("a game result is already waiting for confirmation")
+	}
+
+	state.PendingResult = &types.ResultReport{
+		By:     event.User,
+		Winner: event.Side,
 	}
 
 	return true, nil
 }
 
+func (ds *DraftService) handleResultAccept(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	report := ds.lobby.DraftState.PendingResult
+	if report == nil || report.By == event.User {
+		return false, fmt.Errorf("no game result to accept")
+	}
+
+	ds.finishGame(report.Winner, report.By, sendStateFunc)
+
+	return true, nil
+}
+
+func (ds *DraftService) handleResultDecline(event *types.Event) (bool, error) {
+	report := ds.lobby.DraftState.PendingResult
+	if report == nil || report.By == event.User {
+		return false, fmt.Errorf("no game result to decline")
+	}
+
+	ds.lobby.DraftState.PendingResult = nil
+
+	return true, nil
+}
+
+func (ds *DraftService) finishGame(winner types.DraftTurn, reporter types.DraftTurn, sendStateFunc func(*types.Lobby)) {
+	state := &ds.lobby.DraftState
+	state.PendingResult = nil
+	state.PendingUndo = ""
+
+	state.Series = append(state.Series, types.GameRecord{
+		Game:    state.Game,
+		Blue:    ds.recordSide(&state.BlueTeam),
+		Red:     ds.recordSide(&state.RedTeam),
+		Winner:  winner,
+		History: append([]types.HistoryEntry{}, state.History...),
+	})
+
+	if winner != "" {
+		ds.getTeamState(ds.determineTeamKey(winner)).Wins++
+	}
+
+	winsNeeded := state.Options.SeriesLength/2 + 1
+	if state.BlueTeam.Wins >= winsNeeded || state.RedTeam.Wins >= winsNeeded ||
+		state.Game >= state.Options.SeriesLength {
+		state.Phase = types.PhaseOver
+		state.Turn = types.TurnEnd
+		return
+	}
+
+	ds.startSideSelection(ds.sideChooser(winner, reporter), false)
+	ds.resetTimer()
+	ds.startTimer(sendStateFunc)
+}
+
+func (ds *DraftService) recordSide(team *types.TeamState) types.SideRecord {
+	return types.SideRecord{
+		Team:      team.Name,
+		Picks:     ds.extractPreviousPicks(team.Picks),
+		Bans:      ds.extractPreviousBans(team.Bans),
+		Positions: team.Positions,
+	}
+}
+
+func (ds *DraftService) otherSide(side types.DraftTurn) types.DraftTurn {
+	switch side {
+	case types.TurnBlue:
+		return types.TurnRed
+	case types.TurnRed:
+		return types.TurnBlue
+	}
+	return side
+}
+
 func (ds *DraftService) handleWaitingConfirm(event *types.Event, turn types.DraftTurn, action func()) {
 	if ds.lobby.DraftState.Turn == turn {
-		if event.User == types.TurnBlue {
-			ds.lobby.DraftState.Turn = types.TurnRed
-		} else if event.User == types.TurnRed {
-			ds.lobby.DraftState.Turn = types.TurnBlue
-		}
+		ds.lobby.DraftState.Turn = ds.otherSide(event.User)
 	} else {
 		action()
 	}
@@ -228,7 +429,7 @@ func (ds *DraftService) handleRestart(switchSide bool) {
 		blueSide, redSide = redSide, blueSide
 	}
 
-	if ds.lobby.DraftState.Options.IsFearless {
+	if ds.lobby.DraftState.Options.FearlessMode != types.FearlessOff {
 		blueSide.PreviousPicks = append(blueSide.PreviousPicks, ds.extractPreviousPicks(blueSide.Picks)...)
 		redSide.PreviousPicks = append(redSide.PreviousPicks, ds.extractPreviousPicks(redSide.Picks)...)
 
@@ -241,15 +442,31 @@ func (ds *DraftService) handleRestart(switchSide bool) {
 	// Reset picks and bans
 	blueSide.Picks = make([]*types.DraftChampion, 5)
 	redSide.Picks = make([]*types.DraftChampion, 5)
-	blueSide.Bans = make([]*string, 5)
-	redSide.Bans = make([]*string, 5)
+	blueSide.Bans = make([]*types.DraftChampion, 5)
+	redSide.Bans = make([]*types.DraftChampion, 5)
+
+	blueSide.Undos = 0
+	redSide.Undos = 0
+	blueSide.Pauses = 0
+	redSide.Pauses = 0
+	blueSide.Positions = nil
+	redSide.Positions = nil
+	blueSide.TimeBank = ds.lobby.DraftState.Options.TimeBank
+	redSide.TimeBank = ds.lobby.DraftState.Options.TimeBank
 
 	ds.turnCounter = 1
+	ds.lobby.DraftState.History = []types.HistoryEntry{}
+	ds.lobby.DraftState.PendingUndo = ""
+	ds.lobby.DraftState.Pause = nil
 	ds.lobby.DraftState.Phase = types.PhaseReady
 	ds.lobby.DraftState.Game++
 	ds.lobby.DraftState.Turn = types.TurnStart
 	ds.lobby.DraftState.BlueTeam = blueSide
 	ds.lobby.DraftState.RedTeam = redSide
+	ds.resetTimer()
+
+	ds.updateUnavailableChampions()
+	ds.resetChampionAvailability()
 }
 
 func (ds *DraftService) extractPreviousPicks(picks []*types.DraftChampion) []string {
@@ -264,11 +481,11 @@ func (ds *DraftService) extractPreviousPicks(picks []*types.DraftChampion) []str
 	return previousPicks
 }
 
-func (ds *DraftService) extractPreviousBans(bans []*string) []string {
+func (ds *DraftService) extractPreviousBans(bans []*types.DraftChampion) []string {
 	previousBans := []string{}
 	for _, ban := range bans {
-		if ban != nil {
-			previousBans = append(previousBans, *ban)
+		if ban != nil && ban.Status == types.ChampStatusSelected {
+			previousBans = append(previousBans, ban.ID)
 		} else {
 			previousBans = append(previousBans, "none")
 		}
@@ -277,60 +494,63 @@ func (ds *DraftService) extractPreviousBans(bans []*string) []string {
 }
 
 func (ds *DraftService) handleHoverEvent(event *types.Event) (bool, error) {
+	step, ok := ds.currentStep()
+	if !ok || ds.lobby.DraftState.Phase != step.Action.Phase() {
+		return false, fmt.Errorf("champions can only be hovered during your pick or ban")
+	}
 
-	teamKey := ds.determineTeamKey(event.User)
+	champion, err := ds.validateChampion(step.Side, step.Action, event.Payload.ID)
+	if err != nil {
+		return false, err
+	}
+	if champion == nil {
+		return false, fmt.Errorf("nothing to hover")
+	}
 
-	team := ds.getTeamState(teamKey)
+	team := ds.getTeamState(ds.determineTeamKey(step.Side))
 
-	hoverChampion := &types.DraftChampion{
-		ID:     event.Payload.ID,
-		Name:   event.Payload.Name,
-		Roles:  event.Payload.Role,
+	team.Slots(step.Action)[step.Slot] = &types.DraftChampion{
+		ID:     champion.ID,
+		Name:   champion.Name,
+		Roles:  champion.Roles,
 		Status: types.ChampStatusHover,
 	}
-	updated := ds.updateChampionArray(team.Picks, hoverChampion)
-
-	if !updated {
-		log.Println("Unable to hover the champion")
-	}
 
 	return true, nil
 }
 
 func (ds *DraftService) handleSelectEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	step, ok := ds.currentStep()
+	if !ok || ds.lobby.DraftState.Phase != step.Action.Phase() {
+		return false, fmt.Errorf("no pick or ban is in progress")
+	}
 
-	teamKey := ds.determineTeamKey(event.User)
-	team := ds.getTeamState(teamKey)
+	champion, err := ds.validateChampion(step.Side, step.Action, event.Payload.ID)
+	if err != nil {
+		return false, err
+	}
 
-	isBanPhase := ds.lobby.DraftState.Phase == types.PhaseBan
+	ds.stopTimer()
 
-	var updated bool
-	if isBanPhase {
-		updated = ds.updateStringArray(team.Bans, &event.Payload.ID)
+	team := ds.getTeamState(ds.determineTeamKey(step.Side))
+
+	if champion == nil {
+		team.Slots(step.Action)[step.Slot] = skippedBan()
 	} else {
-		selectedChampion := &types.DraftChampion{
-			ID:     event.Payload.ID,
-			Name:   event.Payload.Name,
-			Roles:  event.Payload.Role,
+		team.Slots(step.Action)[step.Slot] = &types.DraftChampion{
+			ID:     champion.ID,
+			Name:   champion.Name,
+			Roles:  champion.Roles,
 			Status: types.ChampStatusSelected,
 		}
-
-		updated = ds.updateChampionArray(team.Picks, selectedChampion)
-	}
-
-	if !updated {
-		log.Printf("Unable to %s the champion", map[bool]string{true: "ban", false: "select"}[isBanPhase])
-		return true, nil
 	}
 
 	ds.setChampionStatusToDisabled(event.Payload.ID)
 
-	ds.turnCounter++
-	ds.updatePhaseAndTurn()
+	ds.completeStep(step, event.Payload.ID, false)
 
-	ds.lobby.DraftState.Timer = 30
-	sendStateFunc(ds.lobby)
-	ds.StartTimer(sendStateFunc)
+	ds.resetTimer()
+	ds.startTimer(sendStateFunc)
 
 	return true, nil
 }
@@ -358,116 +578,59 @@ func (ds *DraftService) getTeamState(teamKey string) *types.TeamState {
 	return &ds.lobby.DraftState.RedTeam
 }
 
-func (ds *DraftService) updateChampionArray(arr []*types.DraftChampion, value *types.DraftChampion) bool {
-	for i := range arr {
-		if arr[i] != nil && arr[i].Status == types.ChampStatusHover {
-			arr[i] = value
-			return true
-		}
-	}
-
-	for i := range arr {
-		if arr[i] == nil {
-			arr[i] = value
-			return true
-		}
-	}
-
-	return false
-}
-
-func (ds *DraftService) updateStringArray(arr []*string, value *string) bool {
-	for i := range arr {
-		if arr[i] == nil {
-			arr[i] = value
-			return true
-		}
+func (ds *DraftService) currentStep() (types.DraftStep, bool) {
+	steps := ds.lobby.DraftState.Options.Format.Steps
+	if ds.turnCounter < 1 || ds.turnCounter > len(steps) {
+		return types.DraftStep{}, false
 	}
-	return false
+	return steps[ds.turnCounter-1], true
 }
 
 func (ds *DraftService) updatePhaseAndTurn() {
-	if ds.lobby.DraftState.Options.TournamentBan {
-		ds.lobby.DraftState.Phase = ds.determinePhase(ds.turnCounter)
-		ds.lobby.DraftState.Turn = ds.getTurn(ds.turnCounter)
-	} else {
-		ds.lobby.DraftState.Phase = ds.determineStandardPhase(ds.turnCounter)
-		ds.lobby.DraftState.Turn = ds.getStandardTurn(ds.turnCounter)
+	step, ok := ds.currentStep()
+	if !ok {
+		ds.lobby.DraftState.Phase = types.PhaseEnd
+		ds.lobby.DraftState.Turn = types.TurnEnd
+		return
 	}
+	ds.lobby.DraftState.Phase = step.Action.Phase()
+	ds.lobby.DraftState.Turn = step.Side
 }
 
-func (ds *DraftService) determineStandardPhase(turnCounter int) types.DraftPhase {
-	if turnCounter <= 10 {
-		return types.PhaseBan
-	}
-	if turnCounter <= 20 {
-		return types.PhasePick
-	}
-	return types.PhaseEnd
-}
+// CaptainJoined starts the clock of the game 1 side choice once a captain is
+// connected to make it, rather than when the lobby is created.
+func (ds *DraftService) CaptainJoined(sendStateFunc func(*types.Lobby)) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
 
-func (ds *DraftService) determinePhase(turnCounter int) types.DraftPhase {
-	if turnCounter <= 6 {
-		return types.PhaseBan
-	}
-	if turnCounter <= 12 {
-		return types.PhasePick
-	}
-	if turnCounter <= 16 {
-		return types.PhaseBan
-	}
-	if turnCounter <= 20 {
-		return types.PhasePick
+	if ds.timer != nil || ds.lobby.DraftState.Phase != types.PhaseSide {
+		return
 	}
-	return types.PhaseEnd
-}
 
-func (ds *DraftService) getStandardTurn(turnCounter int) types.DraftTurn {
-	switch turnCounter {
-	//bans
-	case 1, 3, 5, 7, 9:
-		return types.TurnBlue
-	case 2, 4, 6, 8, 10:
-		return types.TurnRed
-		//picks
-	case 11, 14, 15, 18, 19:
-		return types.TurnBlue
-	case 12, 13, 16, 17, 20:
-		return types.TurnRed
-		//end
-	case 21:
-		return types.TurnEnd
-	default:
-		panic("Invalid turn counter")
+	ds.startTimer(sendStateFunc)
+	if ds.timer != nil {
+		sendStateFunc(ds.lobby)
 	}
 }
 
-func (ds *DraftService) getTurn(turnCounter int) types.DraftTurn {
-	switch turnCounter {
-	//bans
-	case 1, 3, 5:
-		return types.TurnBlue
-	case 2, 4, 6:
-		return types.TurnRed
-		//picks
-	case 7, 10, 11:
-		return types.TurnBlue
-	case 8, 9, 12:
-		return types.TurnRed
-		//bans
-	case 13, 15:
-		return types.TurnRed
-	case 14, 16:
-		return types.TurnBlue
-		//picks
-	case 17, 20:
-		return types.TurnRed
-	case 18, 19:
-		return types.TurnBlue
-		//end
-	case 21:
-		return types.TurnEnd
-	default:
-		panic("Invalid turn counter")
+// Series returns the records of the finished games followed by the game in
+// progress, if any action was taken in it yet.
+func (ds *DraftService) Series() []types.GameRecord {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	state := &ds.lobby.DraftState
+	games := append([]types.GameRecord{}, state.Series...)
+
+	recorded := len(state.Series) > 0 && state.Series[len(state.Series)-1].Game == state.Game
+	if !recorded && len(state.History) > 0 {
+		games = append(games, types.GameRecord{
+			Game:    state.Game,
+			Blue:    ds.recordSide(&state.BlueTeam),
+			Red:     ds.recordSide(&state.RedTeam),
+			History: append([]types.HistoryEntry{}, state.History...),
+		})
 	}
+
+	return games
 }
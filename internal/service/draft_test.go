@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func newTestLobby(t *testing.T, options types.DraftOptions) *types.Lobby {
+	t.Helper()
+
+	if err := (&LobbyService{}).normalizeOptions(&options); err != nil {
+		t.Fatalf("normalizeOptions: %v", err)
+	}
+
+	champions := []*types.DraftChampion{}
+	for i := range 30 {
+		champions = append(champions, &types.DraftChampion{
+			ID:     fmt.Sprintf("champion%d", i),
+			Name:   fmt.Sprintf("Champion %d", i),
+			Roles:  []types.Role{types.Roles[i%len(types.Roles)]},
+			Status: types.ChampStatusNone,
+		})
+	}
+
+	return types.NewLobby(options, "Blue", "Red", champions, []*string{})
+}
+
+func TestSameSourceSameCoinToss(t *testing.T) {
+	for seed := range int64(10) {
+		choosers := []types.DraftTurn{}
+		for range 2 {
+			lobby := newTestLobby(t, types.DraftOptions{CoinToss: true})
+			NewDraftServiceWithSource(lobby, nil, rand.NewSource(seed))
+
+			if lobby.DraftState.SideSelection == nil {
+				t.Fatalf("seed %d: no side selection after the coin toss", seed)
+			}
+			choosers = append(choosers, lobby.DraftState.SideSelection.Chooser)
+		}
+
+		if choosers[0] != choosers[1] {
+			t.Errorf("seed %d: coin toss gave %s and %s", seed, choosers[0], choosers[1])
+		}
+	}
+}
+
+func TestSameSourceSameTimeoutPick(t *testing.T) {
+	noop := func(*types.Lobby) {}
+
+	for seed := range int64(10) {
+		picks := []string{}
+		for range 2 {
+			lobby := newTestLobby(t, types.DraftOptions{BanTimeoutPolicy: types.TimeoutRandom})
+			ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(seed))
+
+			for _, side := range []types.DraftTurn{types.TurnBlue, types.TurnRed} {
+				if _, err := ds.HandleEvent(&types.Event{User: side, Type: types.Start}, noop); err != nil {
+					t.Fatalf("seed %d: start: %v", seed, err)
+				}
+			}
+			ds.handleTimeout(noop)
+
+			if len(lobby.DraftState.History) != 1 || !lobby.DraftState.History[0].Auto {
+				t.Fatalf("seed %d: expected one automatic action, got %+v", seed, lobby.DraftState.History)
+			}
+			picks = append(picks, lobby.DraftState.History[0].ChampionID)
+		}
+
+		if picks[0] != picks[1] {
+			t.Errorf("seed %d: timeout picked %s and %s", seed, picks[0], picks[1])
+		}
+		if picks[0] == skipBanID {
+			t.Errorf("seed %d: random timeout policy skipped the ban", seed)
+		}
+	}
+}
+
+func startDraft(t *testing.T, ds *DraftService) {
+	t.Helper()
+
+	for _, side := range []types.DraftTurn{types.TurnBlue, types.TurnRed} {
+		sendEvent(t, ds, &types.Event{User: side, Type: types.Start})
+	}
+}
+
+func completeDraft(t *testing.T, ds *DraftService) {
+	t.Helper()
+
+	for {
+		step, ok := ds.currentStep()
+		if !ok {
+			return
+		}
+		for _, champion := range ds.lobby.Champions {
+			if _, err := ds.validateChampion(step.Side, step.Action, champion.ID); err == nil {
+				sendEvent(t, ds, &types.Event{User: step.Side, Type: types.Select, Payload: types.Payload{ID: champion.ID}})
+				break
+			}
+		}
+	}
+}
+
+func sendEvent(t *testing.T, ds *DraftService, event *types.Event) {
+	t.Helper()
+
+	if _, err := ds.HandleEvent(event, func(*types.Lobby) {}); err != nil {
+		t.Fatalf("%s from %s: %v", event.Type, event.User, err)
+	}
+}
+
+func TestStartCannotSkipReportedResult(t *testing.T) {
+	lobby := newTestLobby(t, types.DraftOptions{})
+	ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(1))
+	startDraft(t, ds)
+	completeDraft(t, ds)
+
+	sendEvent(t, ds, &types.Event{User: types.TurnBlue, Type: types.Result, Side: types.TurnBlue})
+	if _, err := ds.HandleEvent(&types.Event{User: types.TurnRed, Type: types.Start}, func(*types.Lobby) {}); err == nil {
+		t.Fatal("START ended the game while a result was waiting for confirmation")
+	}
+
+	sendEvent(t, ds, &types.Event{User: types.TurnRed, Type: types.ResultAccept})
+	if lobby.DraftState.BlueTeam.Wins != 1 || lobby.DraftState.Series[0].Winner != types.TurnBlue {
+		t.Fatalf("expected the confirmed result to count, got %+v", lobby.DraftState.Series)
+	}
+}
+
+func TestUnscoredGameEndNeedsBothCaptains(t *testing.T) {
+	lobby := newTestLobby(t, types.DraftOptions{})
+	ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(1))
+	startDraft(t, ds)
+	completeDraft(t, ds)
+
+	sendEvent(t, ds, &types.Event{User: types.TurnRed, Type: types.Start})
+	if lobby.DraftState.Phase != types.PhaseEnd || len(lobby.DraftState.Series) != 0 {
+		t.Fatalf("one START ended the game: phase %s", lobby.DraftState.Phase)
+	}
+
+	sendEvent(t, ds, &types.Event{User: types.TurnBlue, Type: types.Start})
+	if lobby.DraftState.Phase != types.PhaseSide || len(lobby.DraftState.Series) != 1 {
+		t.Fatalf("expected the game to end, got phase %s", lobby.DraftState.Phase)
+	}
+}
+
+func TestUndoCannotReopenFinishedGame(t *testing.T) {
+	lobby := newTestLobby(t, types.DraftOptions{UndoLimit: 1})
+	ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(1))
+	startDraft(t, ds)
+	completeDraft(t, ds)
+
+	sendEvent(t, ds, &types.Event{User: types.TurnRed, Type: types.UndoRequest})
+	sendEvent(t, ds, &types.Event{User: types.TurnBlue, Type: types.Result, Side: types.TurnBlue})
+	sendEvent(t, ds, &types.Event{User: types.TurnRed, Type: types.ResultAccept})
+
+	recorded := len(lobby.DraftState.Series[0].History)
+	if _, err := ds.HandleEvent(&types.Event{User: types.TurnBlue, Type: types.UndoAccept}, func(*types.Lobby) {}); err == nil {
+		t.Fatal("an undo was accepted after the game was finished")
+	}
+	if lobby.DraftState.Phase != types.PhaseSide {
+		t.Fatalf("finished game was reopened: phase %s", lobby.DraftState.Phase)
+	}
+	if len(lobby.DraftState.Series[0].History) != recorded {
+		t.Fatal("the recorded history of the finished game changed")
+	}
+}
+
+func TestUntimedDraftRecordsNoTimeRemaining(t *testing.T) {
+	lobby := newTestLobby(t, types.DraftOptions{TimeBank: 10})
+	ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(1))
+	startDraft(t, ds)
+	completeDraft(t, ds)
+
+	for _, entry := range lobby.DraftState.History {
+		if entry.TimeRemaining != 0 || entry.TimeBank != 0 {
+			t.Fatalf("step %d recorded %ds remaining and %ds of time bank without a timer", entry.Step, entry.TimeRemaining, entry.TimeBank)
+		}
+	}
+}
+
+func TestSameSourceSameRolePickWithoutPlayRates(t *testing.T) {
+	noop := func(*types.Lobby) {}
+
+	for seed := range int64(10) {
+		picks := []string{}
+		for range 2 {
+			lobby := newTestLobby(t, types.DraftOptions{
+				BanTimeoutPolicy:  types.TimeoutSkip,
+				PickTimeoutPolicy: types.TimeoutRole,
+			})
+			ds := NewDraftServiceWithSource(lobby, nil, rand.NewSource(seed))
+			startDraft(t, ds)
+
+			for lobby.DraftState.Phase != types.PhasePick {
+				ds.handleTimeout(noop)
+			}
+			ds.handleTimeout(noop)
+
+			last := lobby.DraftState.History[len(lobby.DraftState.History)-1]
+			if last.Action != types.ActionPick || !last.Auto {
+				t.Fatalf("seed %d: expected an automatic pick, got %+v", seed, last)
+			}
+			picks = append(picks, last.ChampionID)
+		}
+
+		if picks[0] != picks[1] {
+			t.Errorf("seed %d: timeout picked %s and %s", seed, picks[0], picks[1])
+		}
+	}
+}
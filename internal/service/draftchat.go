@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+const (
+	chatHistoryLimit = 100
+	chatMaxLength    = 500
+	chatRateLimit    = 5
+	chatRateWindow   = 10 * time.Second
+)
+
+func (ds *DraftService) handleMessageEvent(event *types.Event) (bool, error) {
+	sender := event.Sender
+	if sender == nil {
+		return false, fmt.Errorf("unknown message sender")
+	}
+
+	text := strings.TrimSpace(event.Text)
+	if text == "" {
+		return false, fmt.Errorf("empty message")
+	}
+	if utf8.RuneCountInString(text) > chatMaxLength {
+		return false, fmt.Errorf("messages are limited to %d characters", chatMaxLength)
+	}
+
+	channel := event.Channel
+	switch channel {
+	case "":
+		channel = types.ChatAll
+	case types.ChatAll:
+	case types.ChatTeam:
+		if sender.Role == types.RoleSpectator {
+			return false, fmt.Errorf("spectators can only write to the all channel")
+		}
+	default:
+		return false, fmt.Errorf("invalid chat channel: %s", channel)
+	}
+
+	// Reconnecting must not reset the limit, so it follows the name and not
+	// the connection
+	if !ds.allowMessage(string(sender.Role) + "/" + sender.Username) {
+		return false, fmt.Errorf("you are sending messages too fast")
+	}
+
+	state := &ds.lobby.DraftState
+	state.Chat = append(state.Chat, types.ChatMessage{
+		Role:    sender.Role,
+		Team:    ds.senderTeam(sender),
+		Name:    ds.senderName(sender),
+		Channel: channel,
+		Text:    text,
+		Time:    time.Now(),
+	})
+	if len(state.Chat) > chatHistoryLimit {
+		state.Chat = append([]types.ChatMessage{}, state.Chat[len(state.Chat)-chatHistoryLimit:]...)
+	}
+
+	return true, nil
+}
+
+// allowMessage lets every user send at most chatRateLimit messages within any
+// chatRateWindow.
+func (ds *DraftService) allowMessage(senderKey string) bool {
+	if ds.chatTimes == nil {
+		ds.chatTimes = make(map[string][]time.Time)
+	}
+
+	now := time.Now()
+	recent := []time.Time{}
+	for _, sent := range ds.chatTimes[senderKey] {
+		if now.Sub(sent) < chatRateWindow {
+			recent = append(recent, sent)
+		}
+	}
+
+	if len(recent) >= chatRateLimit {
+		ds.chatTimes[senderKey] = recent
+		return false
+	}
+	ds.chatTimes[senderKey] = append(recent, now)
+	return true
+}
+
+func (ds *DraftService) senderName(sender *types.User) string {
+	if sender.Username != "" {
+		return sender.Username
+	}
+	if team := ds.senderTeam(sender); team != "" {
+		return team
+	}
+	return "Spectator"
+}
+
+// senderTeam returns the team the sender currently drafts for. Teams swap sides
+// between games, so team chat is tied to the team and not to the side.
+func (ds *DraftService) senderTeam(sender *types.User) string {
+	switch sender.Role {
+	case types.RoleBlueTeam:
+		return ds.lobby.DraftState.BlueTeam.Name
+	case types.RoleRedTeam:
+		return ds.lobby.DraftState.RedTeam.Name
+	}
+	return ""
+}
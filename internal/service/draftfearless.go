@@ -0,0 +1,77 @@
+package service
+
+import (
+	"slices"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+// updateUnavailableChampions recomputes, for both teams, the champions the
+// fearless rules take out of their pool from the picks and bans of previous
+// games in the series.
+func (ds *DraftService) updateUnavailableChampions() {
+	state := &ds.lobby.DraftState
+	blueTeam := &state.BlueTeam
+	redTeam := &state.RedTeam
+
+	var shared []string
+	if state.Options.KeepBan {
+		shared = append(shared, blueTeam.PreviousBans...)
+		shared = append(shared, redTeam.PreviousBans...)
+	}
+
+	switch state.Options.FearlessMode {
+	case types.FearlessTeam:
+		blueTeam.UnavailableChampionIds = uniqueChampionIds(shared, blueTeam.PreviousPicks)
+		redTeam.UnavailableChampionIds = uniqueChampionIds(shared, redTeam.PreviousPicks)
+	case types.FearlessGlobal:
+		unavailable := uniqueChampionIds(shared, blueTeam.PreviousPicks, redTeam.PreviousPicks)
+		blueTeam.UnavailableChampionIds = unavailable
+		redTeam.UnavailableChampionIds = append([]string{}, unavailable...)
+	default:
+		blueTeam.UnavailableChampionIds = []string{}
+		redTeam.UnavailableChampionIds = []string{}
+	}
+}
+
+func uniqueChampionIds(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	championIds := []string{}
+	for _, list := range lists {
+		for _, championID := range list {
+			if championID == skipBanID || seen[championID] {
+				continue
+			}
+			seen[championID] = true
+			championIds = append(championIds, championID)
+		}
+	}
+	return championIds
+}
+
+// resetChampionAvailability rebuilds the status of the lobby roster at the
+// start of a game: only lobby-wide disabled champions and champions that are
+// unavailable to both teams stay disabled.
+func (ds *DraftService) resetChampionAvailability() {
+	state := &ds.lobby.DraftState
+
+	disabled := make(map[string]bool)
+	for _, championID := range state.DisabledChampionIds {
+		if championID != nil {
+			disabled[*championID] = true
+		}
+	}
+	for _, championID := range state.BlueTeam.UnavailableChampionIds {
+		if slices.Contains(state.RedTeam.UnavailableChampionIds, championID) {
+			disabled[championID] = true
+		}
+	}
+
+	for _, champion := range ds.lobby.Champions {
+		if disabled[champion.ID] {
+			champion.Status = types.ChampStatusDisabled
+		} else {
+			champion.Status = types.ChampStatusNone
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func (ds *DraftService) handlePauseEvent(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.Phase != types.PhaseBan && state.Phase != types.PhasePick {
+		return false, fmt.Errorf("the draft can only be paused during bans and picks")
+	}
+	if state.Pause != nil {
+		return false, fmt.Errorf("the draft is already paused")
+	}
+
+	team := ds.getTeamState(ds.determineTeamKey(event.User))
+	if team.Pauses >= state.Options.PauseLimit {
+		return false, fmt.Errorf("no pauses left for this game")
+	}
+
+	ds.stopTimer()
+
+	team.Pauses++
+	state.Pause = &types.PauseState{
+		By:    event.User,
+		Since: time.Now(),
+	}
+
+	return true, nil
+}
+
+// handleResumeEvent only lets the team that paused resume, so a pause taken
+// for technical issues lasts until that team is ready again.
+func (ds *DraftService) handleResumeEvent(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	if ds.lobby.DraftState.Pause == nil {
+		return false, fmt.Errorf("the draft is not paused")
+	}
+	if ds.lobby.DraftState.Pause.By != event.User {
+		return false, fmt.Errorf("only the team that paused can resume the draft")
+	}
+
+	ds.lobby.DraftState.Pause = nil
+	ds.startTimer(sendStateFunc)
+
+	return true, nil
+}
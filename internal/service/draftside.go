@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func (ds *DraftService) tossCoin() types.DraftTurn {
+	if ds.rng.Intn(2) == 0 {
+		return types.TurnBlue
+	}
+	return types.TurnRed
+}
+
+// sideChooser returns the side that picks blue/red for the next game. When no
+// result was reported, the team that did not end the game gets the choice.
+func (ds *DraftService) sideChooser(winner types.DraftTurn, reporter types.DraftTurn) types.DraftTurn {
+	switch ds.lobby.DraftState.Options.SideChooser {
+	case types.SideChooserBlue:
+		return types.TurnBlue
+	case types.SideChooserRed:
+		return types.TurnRed
+	case types.SideChooserWinner:
+		if winner != "" {
+			return winner
+		}
+	default:
+		if winner != "" {
+			return ds.otherSide(winner)
+		}
+	}
+	return ds.otherSide(reporter)
+}
+
+func (ds *DraftService) startSideSelection(chooser types.DraftTurn, coinToss bool) {
+	ds.lobby.DraftState.Phase = types.PhaseSide
+	ds.lobby.DraftState.Turn = chooser
+	ds.lobby.DraftState.SideSelection = &types.SideSelection{
+		Chooser:  chooser,
+		Team:     ds.getTeamState(ds.determineTeamKey(chooser)).Name,
+		CoinToss: coinToss,
+	}
+}
+
+func (ds *DraftService) handleSideEvent(event *types.Event) (bool, error) {
+	if ds.lobby.DraftState.Phase != types.PhaseSide {
+		return false, fmt.Errorf("sides can only be chosen between games")
+	}
+	if event.Side != types.TurnBlue && event.Side != types.TurnRed {
+		return false, fmt.Errorf("invalid side: %s", event.Side)
+	}
+
+	ds.chooseSide(event.Side)
+
+	return true, nil
+}
+
+// chooseSide applies the side the chooser wants to play on and moves on to the
+// ready check of the next game.
+func (ds *DraftService) chooseSide(side types.DraftTurn) {
+	ds.stopTimer()
+
+	selection := ds.lobby.DraftState.SideSelection
+	selection.Choice = side
+	switchSide := side != selection.Chooser
+
+	if selection.CoinToss && ds.lobby.DraftState.Game == 1 {
+		if switchSide {
+			state := &ds.lobby.DraftState
+			state.BlueTeam, state.RedTeam = state.RedTeam, state.BlueTeam
+		}
+		ds.lobby.DraftState.Phase = types.PhaseReady
+		ds.lobby.DraftState.Turn = types.TurnStart
+		ds.resetTimer()
+		return
+	}
+
+	ds.handleRestart(switchSide)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"log"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+// resolveTimeout fills the slot of an expired step according to the timeout
+// policy of the lobby and returns the champion that was locked, or "none".
+func (ds *DraftService) resolveTimeout(step types.DraftStep) string {
+	options := ds.lobby.DraftState.Options
+	team := ds.getTeamState(ds.determineTeamKey(step.Side))
+
+	var champion *types.DraftChampion
+	if step.Action == types.ActionBan {
+		switch options.BanTimeoutPolicy {
+		case types.TimeoutHover:
+			champion = ds.isAnyChampionInHoverState(team.Bans, step.Slot)
+		case types.TimeoutRandom:
+			champion = ds.getRandomChampion(step, false)
+		}
+	} else {
+		switch options.PickTimeoutPolicy {
+		case types.TimeoutHover:
+			champion = ds.isAnyChampionInHoverState(team.Picks, step.Slot)
+			if champion == nil {
+				champion = ds.getRandomChampion(step, true)
+			}
+		case types.TimeoutRandom:
+			champion = ds.getRandomChampion(step, false)
+		case types.TimeoutRole:
+			champion = ds.getRandomChampion(step, true)
+		}
+	}
+
+	if champion == nil {
+		if step.Action == types.ActionBan {
+			team.Bans[step.Slot] = skippedBan()
+		} else {
+			team.Picks[step.Slot] = nil
+		}
+		return skipBanID
+	}
+
+	team.Slots(step.Action)[step.Slot] = &types.DraftChampion{
+		ID:     champion.ID,
+		Name:   champion.Name,
+		Roles:  champion.Roles,
+		Status: types.ChampStatusSelected,
+	}
+	ds.setChampionStatusToDisabled(champion.ID)
+
+	return champion.ID
+}
+
+func (ds *DraftService) isAnyChampionInHoverState(slots []*types.DraftChampion, slot int) *types.DraftChampion {
+	pick := slots[slot]
+	if pick != nil && pick.Status == types.ChampStatusHover {
+		return pick
+	}
+	return nil
+}
+
+func (ds *DraftService) getRandomChampion(step types.DraftStep, roleAware bool) *types.DraftChampion {
+	availableChampions := []*types.DraftChampion{}
+
+	for _, champion := range ds.lobby.Champions {
+		if _, err := ds.validateChampion(step.Side, step.Action, champion.ID); err == nil {
+			availableChampions = append(availableChampions, champion)
+		}
+	}
+
+	if roleAware {
+		team := ds.getTeamState(ds.determineTeamKey(step.Side))
+		if champion := ds.pickForMissingRole(team.Picks, availableChampions); champion != nil {
+			return champion
+		}
+	}
+
+	if len(availableChampions) == 0 {
+		log.Println("No available champions to select")
+		return nil
+	}
+
+	return availableChampions[ds.rng.Intn(len(availableChampions))]
+}
+
+// pickForMissingRole draws a champion that can play a role the current picks
+// leave uncovered, weighted by its play rate in those roles when rates are
+// available. It returns nil when no champion fits a missing role. The rates
+// change as they are refreshed, so the seed alone does not reproduce this draw.
+func (ds *DraftService) pickForMissingRole(picks []*types.DraftChampion, champions []*types.DraftChampion) *types.DraftChampion {
+	pickedRoles := [][]types.Role{}
+	for _, pick := range picks {
+		if pick != nil && pick.Status == types.ChampStatusSelected {
+			pickedRoles = append(pickedRoles, pick.Roles)
+		}
+	}
+
+	candidates := []*types.DraftChampion{}
+	weights := []float64{}
+	totalWeight := 0.0
+	for _, champion := range champions {
+		weight := 0.0
+		fits := false
+		for _, role := range champion.Roles {
+			roles := append(append([][]types.Role{}, pickedRoles...), []types.Role{role})
+			if !canAssignRoles(roles) {
+				continue
+			}
+			fits = true
+			weight += ds.rolePlayRate(champion.ID, role)
+		}
+		if fits {
+			candidates = append(candidates, champion)
+			weights = append(weights, weight)
+			totalWeight += weight
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+	if totalWeight <= 0 {
+		return candidates[ds.rng.Intn(len(candidates))]
+	}
+
+	target := ds.rng.Float64() * totalWeight
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return candidates[i]
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// rolePlayRate weights every role equally until play rates have been loaded.
+func (ds *DraftService) rolePlayRate(championID string, role types.Role) float64 {
+	if ds.playRates == nil {
+		return 1
+	}
+	rate, loaded := ds.playRates.PlayRate(championID, role)
+	if !loaded {
+		return 1
+	}
+	return rate
+}
+
+// canAssignRoles reports whether every champion can be given a distinct role
+// out of the roles it can play, using augmenting paths on the bipartite graph.
+func canAssignRoles(championRoles [][]types.Role) bool {
+	assigned := make(map[types.Role]int)
+
+	var assign func(champion int, visited map[types.Role]bool) bool
+	assign = func(champion int, visited map[types.Role]bool) bool {
+		for _, role := range championRoles[champion] {
+			if visited[role] {
+				continue
+			}
+			visited[role] = true
+			owner, taken := assigned[role]
+			if !taken || assign(owner, visited) {
+				assigned[role] = champion
+				return true
+			}
+		}
+		return false
+	}
+
+	for champion := range championRoles {
+		if !assign(champion, make(map[types.Role]bool)) {
+			return false
+		}
+	}
+	return true
+}
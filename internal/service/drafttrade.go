@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func (ds *DraftService) startTrade() {
+	ds.lobby.DraftState.Phase = types.PhaseTrade
+	ds.lobby.DraftState.Turn = types.TurnStart
+}
+
+func (ds *DraftService) finishTrade() {
+	ds.stopTimer()
+	ds.lobby.DraftState.Phase = types.PhaseEnd
+	ds.lobby.DraftState.Turn = types.TurnEnd
+	ds.lobby.DraftState.Timer = 0
+}
+
+// handleTradeEvent reorders the picks of the sending team. Order[i] is the
+// current index of the champion that should end up in slot i.
+func (ds *DraftService) handleTradeEvent(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.Phase != types.PhaseTrade {
+		return false, fmt.Errorf("champions can only be traded during the trade phase")
+	}
+	if state.Turn != types.TurnStart && state.Turn != event.User {
+		return false, fmt.Errorf("your team already confirmed its trades")
+	}
+
+	team := ds.getTeamState(ds.determineTeamKey(event.User))
+	if !isPermutation(event.Order, len(team.Picks)) {
+		return false, fmt.Errorf("invalid trade order")
+	}
+
+	team.Picks = reorder(team.Picks, event.Order)
+	if team.Positions != nil {
+		team.Positions = reorder(team.Positions, event.Order)
+	}
+
+	return true, nil
+}
+
+// handlePositionsEvent records which position each pick slot of the sending
+// team will play, once the picks are locked in.
+func (ds *DraftService) handlePositionsEvent(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.Phase != types.PhaseTrade && state.Phase != types.PhaseEnd {
+		return false, fmt.Errorf("positions can only be assigned once the draft is over")
+	}
+
+	team := ds.getTeamState(ds.determineTeamKey(event.User))
+	if len(event.Positions) != len(team.Picks) {
+		return false, fmt.Errorf("expected %d positions, got %d", len(team.Picks), len(event.Positions))
+	}
+
+	assigned := make(map[types.Role]bool)
+	for _, role := range event.Positions {
+		if !role.IsValid() {
+			return false, fmt.Errorf("invalid position: %q", role)
+		}
+		if assigned[role] {
+			return false, fmt.Errorf("position %q is assigned twice", role)
+		}
+		assigned[role] = true
+	}
+
+	team.Positions = append([]types.Role{}, event.Positions...)
+
+	return true, nil
+}
+
+func isPermutation(order []int, size int) bool {
+	if len(order) != size {
+		return false
+	}
+	seen := make([]bool, size)
+	for _, index := range order {
+		if index < 0 || index >= size || seen[index] {
+			return false
+		}
+		seen[index] = true
+	}
+	return true
+}
+
+func reorder[T any](values []T, order []int) []T {
+	reordered := make([]T, len(values))
+	for i, index := range order {
+		reordered[i] = values[index]
+	}
+	return reordered
+}
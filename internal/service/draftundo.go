@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+func (ds *DraftService) completeStep(step types.DraftStep, championID string, auto bool) {
+	team := ds.getTeamState(ds.determineTeamKey(step.Side))
+	entry := types.HistoryEntry{
+		Step:       ds.turnCounter,
+		Side:       step.Side,
+		Team:       team.Name,
+		Action:     step.Action,
+		Slot:       step.Slot,
+		ChampionID: championID,
+		Auto:       auto,
+		Time:       time.Now(),
+	}
+	// Untimed drafts still carry the nominal turn duration, which was never used
+	if ds.lobby.DraftState.HasTimer {
+		entry.TimeRemaining = max(ds.lobby.DraftState.Timer, 0)
+		entry.TimeBank = team.TimeBank
+	}
+	ds.lobby.DraftState.History = append(ds.lobby.DraftState.History, entry)
+
+	// A pending undo targets the previous action, not the one just locked
+	ds.lobby.DraftState.PendingUndo = ""
+
+	ds.turnCounter++
+	ds.updatePhaseAndTurn()
+
+	if ds.lobby.DraftState.Phase == types.PhaseEnd && ds.lobby.DraftState.Options.TradePhase {
+		ds.startTrade()
+	}
+}
+
+func (ds *DraftService) handleUndoRequest(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if !ds.canUndo() || len(state.History) == 0 {
+		return false, fmt.Errorf("there is nothing to undo right now")
+	}
+	if state.PendingUndo != "" {
+		return false, fmt.Errorf("an undo request is already pending")
+	}
+
+	team := ds.getTeamState(ds.determineTeamKey(event.User))
+	if team.Undos >= state.Options.UndoLimit {
+		return false, fmt.Errorf("no undos left for this game")
+	}
+
+	state.PendingUndo = event.User
+
+	return true, nil
+}
+
+func (ds *DraftService) handleUndoAccept(event *types.Event, sendStateFunc func(*types.Lobby)) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.PendingUndo == "" || state.PendingUndo == event.User || !ds.canUndo() {
+		return false, fmt.Errorf("no undo request to accept")
+	}
+
+	ds.stopTimer()
+
+	last := state.History[len(state.History)-1]
+	state.History = state.History[:len(state.History)-1]
+
+	team := ds.getTeamState(ds.determineTeamKey(last.Side))
+	team.Slots(last.Action)[last.Slot] = nil
+	ds.setChampionStatusToNone(last.ChampionID)
+
+	ds.getTeamState(ds.determineTeamKey(state.PendingUndo)).Undos++
+	state.PendingUndo = ""
+	// A result reported for the draft that was just changed no longer applies
+	state.PendingResult = nil
+
+	ds.turnCounter--
+	ds.updatePhaseAndTurn()
+
+	ds.resetTimer()
+	ds.startTimer(sendStateFunc)
+
+	return true, nil
+}
+
+func (ds *DraftService) handleUndoDecline(event *types.Event) (bool, error) {
+	state := &ds.lobby.DraftState
+
+	if state.PendingUndo == "" || state.PendingUndo == event.User || !ds.canUndo() {
+		return false, fmt.Errorf("no undo request to decline")
+	}
+
+	state.PendingUndo = ""
+
+	return true, nil
+}
+
+// canUndo reports whether the draft of the current game can still be changed:
+// during bans and picks, and after them until the game is finished, unless the
+// trade phase already moved the picks around.
+func (ds *DraftService) canUndo() bool {
+	state := &ds.lobby.DraftState
+
+	switch state.Phase {
+	case types.PhaseBan, types.PhasePick:
+		return true
+	case types.PhaseEnd:
+		return !state.Options.TradePhase
+	}
+	return false
+}
+
+func (ds *DraftService) setChampionStatusToNone(championID string) {
+	for _, champion := range ds.lobby.Champions {
+		if champion.ID == championID {
+			champion.Status = types.ChampStatusNone
+			break
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+const skipBanID = "none"
+
+func skippedBan() *types.DraftChampion {
+	return &types.DraftChampion{
+		ID:     skipBanID,
+		Status: types.ChampStatusSelected,
+	}
+}
+
+func (ds *DraftService) validateChampion(side types.DraftTurn, action types.DraftAction, championID string) (*types.DraftChampion, error) {
+	if action == types.ActionBan && championID == skipBanID {
+		return nil, nil
+	}
+
+	champion := ds.findChampion(championID)
+	if champion == nil {
+		return nil, fmt.Errorf("champion %q is not in the lobby roster", championID)
+	}
+
+	for _, disabledID := range ds.lobby.DraftState.DisabledChampionIds {
+		if disabledID != nil && *disabledID == championID {
+			return nil, fmt.Errorf("champion %q is disabled in this lobby", championID)
+		}
+	}
+
+	if champion.Status == types.ChampStatusDisabled || ds.isChampionInCurrentDraft(championID) {
+		return nil, fmt.Errorf("champion %q has already been picked or banned", championID)
+	}
+
+	state := &ds.lobby.DraftState
+	if action == types.ActionPick && slices.Contains(ds.getTeamState(ds.determineTeamKey(side)).UnavailableChampionIds, championID) {
+		return nil, fmt.Errorf("champion %q is no longer available to your team in this series", championID)
+	}
+	if action == types.ActionBan &&
+		slices.Contains(state.BlueTeam.UnavailableChampionIds, championID) &&
+		slices.Contains(state.RedTeam.UnavailableChampionIds, championID) {
+		return nil, fmt.Errorf("champion %q is no longer available in this series", championID)
+	}
+
+	return champion, nil
+}
+
+func (ds *DraftService) findChampion(championID string) *types.DraftChampion {
+	for _, champion := range ds.lobby.Champions {
+		if champion.ID == championID {
+			return champion
+		}
+	}
+	return nil
+}
+
+func (ds *DraftService) isChampionInCurrentDraft(championID string) bool {
+	for _, team := range []*types.TeamState{&ds.lobby.DraftState.BlueTeam, &ds.lobby.DraftState.RedTeam} {
+		for _, pick := range team.Picks {
+			if pick != nil && pick.Status == types.ChampStatusSelected && pick.ID == championID {
+				return true
+			}
+		}
+		for _, ban := range team.Bans {
+			if ban != nil && ban.Status == types.ChampStatusSelected && ban.ID == championID {
+				return true
+			}
+		}
+	}
+	return false
+}
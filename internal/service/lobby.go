@@ -8,10 +8,13 @@ import (
 	"fearlessdraft-server/pkg/types"
 )
 
+const replayRetention = 24 * time.Hour
+
 type LobbyService struct {
 	lobbies      map[string]*types.Lobby
 	lobbiesMutex sync.RWMutex
 	lobbyTimeout time.Duration
+	playRates    PlayRateSource
 }
 
 type LobbyCreateResponse struct {
@@ -21,10 +24,11 @@ type LobbyCreateResponse struct {
 	SpectatorURL string `json:"spectatorUrl"`
 }
 
-func NewLobbyService() *LobbyService {
+func NewLobbyService(playRates PlayRateSource) *LobbyService {
 	service := &LobbyService{
 		lobbies:      make(map[string]*types.Lobby),
 		lobbyTimeout: 5 * time.Minute,
+		playRates:    playRates,
 	}
 	go service.cleanupLobbies()
 	return service
@@ -35,22 +39,47 @@ func (s *LobbyService) cleanupLobbies() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		s.lobbiesMutex.Lock()
-		for lobbyID, lobby := range s.lobbies {
-			if s.isLobbyInactive(lobby) {
-				delete(s.lobbies, lobbyID)
-				fmt.Printf("Removed inactive lobby: %s\n", lobbyID)
+		s.lobbiesMutex.RLock()
+		lobbies := make([]*types.Lobby, 0, len(s.lobbies))
+		for _, lobby := range s.lobbies {
+			lobbies = append(lobbies, lobby)
+		}
+		s.lobbiesMutex.RUnlock()
+
+		// Checking a lobby can wait on its draft, so it happens without
+		// blocking lookups and creation of other lobbies
+		for _, lobby := range lobbies {
+			if !s.isLobbyInactive(lobby) {
+				continue
+			}
+
+			s.lobbiesMutex.Lock()
+			if s.lobbies[lobby.ID] == lobby {
+				delete(s.lobbies, lobby.ID)
+				fmt.Printf("Removed inactive lobby: %s\n", lobby.ID)
 			}
+			s.lobbiesMutex.Unlock()
 		}
-		s.lobbiesMutex.Unlock()
 	}
 }
 
+// isLobbyInactive keeps empty lobbies around for a while so they can be
+// rejoined, and much longer once games were played so they can be replayed.
 func (s *LobbyService) isLobbyInactive(lobby *types.Lobby) bool {
 	lobby.Mutex.RLock()
-	defer lobby.Mutex.RUnlock()
+	users := len(lobby.Users)
+	lastActivity := lobby.LastActivityTime
+	lobby.Mutex.RUnlock()
 
-	return len(lobby.Users) == 0
+	if users > 0 {
+		return false
+	}
+
+	timeout := s.lobbyTimeout
+	if len(lobby.DraftService.Series()) > 0 {
+		timeout = replayRetention
+	}
+	return time.Since(lastActivity) > timeout
 }
 
 func (s *LobbyService) CreateLobby(
@@ -59,11 +88,16 @@ func (s *LobbyService) CreateLobby(
 	redTeamName string,
 	champions []*types.DraftChampion,
 	disabledChampionIds []*string,
-) *LobbyCreateResponse {
+) (*LobbyCreateResponse, error) {
+	if err := s.normalizeOptions(options); err != nil {
+		return nil, err
+	}
+
 	s.lobbiesMutex.Lock()
 	defer s.lobbiesMutex.Unlock()
 
 	lobby := types.NewLobby(*options, blueTeamName, redTeamName, champions, disabledChampionIds)
+	lobby.DraftService = NewDraftService(lobby, s.playRates)
 
 	s.lobbies[lobby.ID] = lobby
 
@@ -73,7 +107,96 @@ func (s *LobbyService) CreateLobby(
 		BlueTeamURL:  fmt.Sprintf("%s/%s/blue", baseURL, lobby.ID),
 		RedTeamURL:   fmt.Sprintf("%s/%s/red", baseURL, lobby.ID),
 		SpectatorURL: fmt.Sprintf("%s/%s/spectator", baseURL, lobby.ID),
+	}, nil
+}
+
+func (s *LobbyService) normalizeOptions(options *types.DraftOptions) error {
+	if options == nil {
+		return fmt.Errorf("missing draft options")
+	}
+
+	format, err := options.ResolveDraftFormat()
+	if err != nil {
+		return fmt.Errorf("invalid draft format: %w", err)
+	}
+	options.Format = &format
+
+	switch options.SeriesLength {
+	case 0:
+		options.SeriesLength = types.DefaultSeriesLength
+	case 1, 3, 5, 7:
+	default:
+		return fmt.Errorf("invalid series length: %d", options.SeriesLength)
+	}
+
+	switch options.FearlessMode {
+	case "":
+		// Lobbies created before fearless modes existed only send the flag
+		if options.IsFearless {
+			options.FearlessMode = types.FearlessTeam
+		} else {
+			options.FearlessMode = types.FearlessOff
+		}
+	case types.FearlessOff, types.FearlessTeam, types.FearlessGlobal:
+	default:
+		return fmt.Errorf("invalid fearless mode: %q", options.FearlessMode)
 	}
+	options.IsFearless = options.FearlessMode != types.FearlessOff
+
+	if options.UndoLimit < 0 {
+		return fmt.Errorf("invalid undo limit: %d", options.UndoLimit)
+	}
+
+	if options.PauseLimit < 0 {
+		return fmt.Errorf("invalid pause limit: %d", options.PauseLimit)
+	}
+
+	switch options.SideChooser {
+	case "":
+		options.SideChooser = types.SideChooserLoser
+	case types.SideChooserLoser, types.SideChooserWinner, types.SideChooserBlue, types.SideChooserRed:
+	default:
+		return fmt.Errorf("invalid side chooser: %q", options.SideChooser)
+	}
+
+	switch options.BanTimeoutPolicy {
+	case "":
+		options.BanTimeoutPolicy = types.TimeoutHover
+	case types.TimeoutSkip, types.TimeoutHover, types.TimeoutRandom:
+	default:
+		return fmt.Errorf("invalid ban timeout policy: %q", options.BanTimeoutPolicy)
+	}
+
+	switch options.PickTimeoutPolicy {
+	case "":
+		options.PickTimeoutPolicy = types.TimeoutHover
+	case types.TimeoutSkip, types.TimeoutHover, types.TimeoutRandom, types.TimeoutRole:
+	default:
+		return fmt.Errorf("invalid pick timeout policy: %q", options.PickTimeoutPolicy)
+	}
+
+	if options.BanTimer < 0 || options.PickTimer < 0 || options.TimeBank < 0 ||
+		options.TradeTimer < 0 || options.SideTimer < 0 {
+		return fmt.Errorf("timer durations cannot be negative")
+	}
+	if options.BanTimer == 0 {
+		options.BanTimer = types.DefaultTurnDuration
+	}
+	if options.PickTimer == 0 {
+		options.PickTimer = types.DefaultTurnDuration
+	}
+	if options.TradePhase && options.TradeTimer == 0 {
+		options.TradeTimer = types.DefaultTradeTimer
+	}
+	if options.SideTimer == 0 {
+		options.SideTimer = types.DefaultSideTimer
+	}
+
+	if options.SpectatorDelay < 0 || options.SpectatorDelay > types.MaxSpectatorDelay {
+		return fmt.Errorf("spectator delay must be between 0 and %d seconds", types.MaxSpectatorDelay)
+	}
+
+	return nil
 }
 
 func (s *LobbyService) GetLobby(lobbyID string) (*types.Lobby, bool) {
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"time"
+
+	"fearlessdraft-server/pkg/types"
+)
+
+const maxReplayStepDelay = 30 * time.Second
+
+type ReplayFrame struct {
+	State types.DraftState
+	Delay time.Duration
+}
+
+// BuildReplay rebuilds the draft state after every recorded action of every
+// game, together with how long to wait before showing it at the given speed.
+func BuildReplay(lobby *types.Lobby, games []types.GameRecord, speed float64) []ReplayFrame {
+	names := make(map[string]string)
+	roles := make(map[string][]types.Role)
+	for _, champion := range lobby.Champions {
+		names[champion.ID] = champion.Name
+		roles[champion.ID] = champion.Roles
+	}
+
+	frames := []ReplayFrame{}
+	blueWins, redWins := 0, 0
+	for i, game := range games {
+		state := types.DraftState{
+			Phase:    types.PhaseReady,
+			Turn:     types.TurnStart,
+			Game:     game.Game,
+			Chat:     []types.ChatMessage{},
+			BlueTeam: replayTeam(game.Blue.Team, blueWins),
+			RedTeam:  replayTeam(game.Red.Team, redWins),
+			Options:  lobby.DraftState.Options,
+			Series:   games[:i],
+			History:  []types.HistoryEntry{},
+		}
+		frames = append(frames, ReplayFrame{State: copyReplayState(state), Delay: time.Second})
+
+		var previous time.Time
+		for _, entry := range game.History {
+			delay := time.Second
+			if !previous.IsZero() {
+				delay = min(entry.Time.Sub(previous), maxReplayStepDelay)
+			}
+			previous = entry.Time
+
+			team := &state.BlueTeam
+			if entry.Side == types.TurnRed {
+				team = &state.RedTeam
+			}
+			switch {
+			case entry.ChampionID != skipBanID:
+				team.Slots(entry.Action)[entry.Slot] = &types.DraftChampion{
+					ID:     entry.ChampionID,
+					Name:   names[entry.ChampionID],
+					Roles:  roles[entry.ChampionID],
+					Status: types.ChampStatusSelected,
+				}
+			case entry.Action == types.ActionBan:
+				team.Bans[entry.Slot] = skippedBan()
+			default:
+				// Skipped picks leave the slot empty, like in the live draft
+				team.Picks[entry.Slot] = nil
+			}
+			state.Phase = entry.Action.Phase()
+			state.Turn = entry.Side
+			state.Timer = entry.TimeRemaining
+			state.History = append(state.History, entry)
+
+			frames = append(frames, ReplayFrame{
+				State: copyReplayState(state),
+				Delay: time.Duration(float64(delay) / speed),
+			})
+		}
+
+		switch game.Winner {
+		case types.TurnBlue:
+			blueWins++
+		case types.TurnRed:
+			redWins++
+		}
+	}
+
+	return frames
+}
+
+// DelayedSeries holds back the actions of the given games that spectators of
+// the lobby have not seen yet, so that history and replays cannot be used to
+// get around the spectator delay.
+func DelayedSeries(lobby *types.Lobby, games []types.GameRecord) []types.GameRecord {
+	delay := time.Duration(lobby.DraftState.Options.SpectatorDelay) * time.Second
+	if delay == 0 {
+		return games
+	}
+
+	cutoff := time.Now().Add(-delay)
+	delayed := []types.GameRecord{}
+	for _, game := range games {
+		history := []types.HistoryEntry{}
+		for _, entry := range game.History {
+			if !entry.Time.After(cutoff) {
+				history = append(history, entry)
+			}
+		}
+
+		if len(history) == len(game.History) {
+			delayed = append(delayed, game)
+			continue
+		}
+		if len(history) == 0 {
+			break
+		}
+
+		delayed = append(delayed, types.GameRecord{
+			Game:    game.Game,
+			Blue:    delayedSide(game.Blue.Team, types.TurnBlue, history),
+			Red:     delayedSide(game.Red.Team, types.TurnRed, history),
+			History: history,
+		})
+		break
+	}
+
+	return delayed
+}
+
+func delayedSide(team string, side types.DraftTurn, history []types.HistoryEntry) types.SideRecord {
+	record := types.SideRecord{
+		Team:  team,
+		Picks: make([]string, types.DraftSlots),
+		Bans:  make([]string, types.DraftSlots),
+	}
+	for i := range types.DraftSlots {
+		record.Picks[i] = skipBanID
+		record.Bans[i] = skipBanID
+	}
+
+	for _, entry := range history {
+		if entry.Side != side {
+			continue
+		}
+		if entry.Action == types.ActionBan {
+			record.Bans[entry.Slot] = entry.ChampionID
+		} else {
+			record.Picks[entry.Slot] = entry.ChampionID
+		}
+	}
+
+	return record
+}
+
+func replayTeam(name string, wins int) types.TeamState {
+	return types.TeamState{
+		Name:                   name,
+		Picks:                  make([]*types.DraftChampion, types.DraftSlots),
+		Bans:                   make([]*types.DraftChampion, types.DraftSlots),
+		PreviousPicks:          []string{},
+		PreviousBans:           []string{},
+		Wins:                   wins,
+		UnavailableChampionIds: []string{},
+	}
+}
+
+func copyReplayState(state types.DraftState) types.DraftState {
+	state.BlueTeam.Picks = append([]*types.DraftChampion{}, state.BlueTeam.Picks...)
+	state.BlueTeam.Bans = append([]*types.DraftChampion{}, state.BlueTeam.Bans...)
+	state.RedTeam.Picks = append([]*types.DraftChampion{}, state.RedTeam.Picks...)
+	state.RedTeam.Bans = append([]*types.DraftChampion{}, state.RedTeam.Bans...)
+	state.History = append([]types.HistoryEntry{}, state.History...)
+	return state
+}
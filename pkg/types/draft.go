@@ -1,5 +1,140 @@
 package types
 
+import "fmt"
+
 type DraftServiceInterface interface {
 	HandleEvent(event *Event, sendStateFunc func(*Lobby)) (bool, error)
+	Series() []GameRecord
+	CaptainJoined(sendStateFunc func(*Lobby))
+}
+
+type DraftAction string
+
+const (
+	ActionBan  DraftAction = "ban"
+	ActionPick DraftAction = "pick"
+)
+
+type DraftStep struct {
+	Side   DraftTurn   `json:"side"`
+	Action DraftAction `json:"action"`
+	Slot   int         `json:"slot"`
+}
+
+type DraftFormat struct {
+	Name  string      `json:"name"`
+	Steps []DraftStep `json:"steps"`
+}
+
+const DraftSlots = 5
+
+var StandardDraftFormat = DraftFormat{
+	Name: "standard",
+	Steps: []DraftStep{
+		//bans
+		{TurnBlue, ActionBan, 0}, {TurnRed, ActionBan, 0},
+		{TurnBlue, ActionBan, 1}, {TurnRed, ActionBan, 1},
+		{TurnBlue, ActionBan, 2}, {TurnRed, ActionBan, 2},
+		{TurnBlue, ActionBan, 3}, {TurnRed, ActionBan, 3},
+		{TurnBlue, ActionBan, 4}, {TurnRed, ActionBan, 4},
+		//picks
+		{TurnBlue, ActionPick, 0},
+		{TurnRed, ActionPick, 0}, {TurnRed, ActionPick, 1},
+		{TurnBlue, ActionPick, 1}, {TurnBlue, ActionPick, 2},
+		{TurnRed, ActionPick, 2}, {TurnRed, ActionPick, 3},
+		{TurnBlue, ActionPick, 3}, {TurnBlue, ActionPick, 4},
+		{TurnRed, ActionPick, 4},
+	},
+}
+
+var TournamentDraftFormat = DraftFormat{
+	Name: "tournament",
+	Steps: []DraftStep{
+		//bans
+		{TurnBlue, ActionBan, 0}, {TurnRed, ActionBan, 0},
+		{TurnBlue, ActionBan, 1}, {TurnRed, ActionBan, 1},
+		{TurnBlue, ActionBan, 2}, {TurnRed, ActionBan, 2},
+		//picks
+		{TurnBlue, ActionPick, 0},
+		{TurnRed, ActionPick, 0}, {TurnRed, ActionPick, 1},
+		{TurnBlue, ActionPick, 1}, {TurnBlue, ActionPick, 2},
+		{TurnRed, ActionPick, 2},
+		//bans
+		{TurnRed, ActionBan, 3}, {TurnBlue, ActionBan, 3},
+		{TurnRed, ActionBan, 4}, {TurnBlue, ActionBan, 4},
+		//picks
+		{TurnRed, ActionPick, 3},
+		{TurnBlue, ActionPick, 3}, {TurnBlue, ActionPick, 4},
+		{TurnRed, ActionPick, 4},
+	},
+}
+
+var BuiltinDraftFormats = map[string]DraftFormat{
+	StandardDraftFormat.Name:   StandardDraftFormat,
+	TournamentDraftFormat.Name: TournamentDraftFormat,
+}
+
+func (a DraftAction) Phase() DraftPhase {
+	if a == ActionBan {
+		return PhaseBan
+	}
+	return PhasePick
+}
+
+// Validate checks that every step targets a valid side, action and slot,
+// that no slot is filled twice and that both teams end up with all their picks.
+func (f DraftFormat) Validate() error {
+	if len(f.Steps) == 0 {
+		return fmt.Errorf("draft format has no steps")
+	}
+
+	used := make(map[DraftStep]bool)
+	picks := map[DraftTurn]int{}
+	for i, step := range f.Steps {
+		if step.Side != TurnBlue && step.Side != TurnRed {
+			return fmt.Errorf("step %d: invalid side %q", i+1, step.Side)
+		}
+		if step.Action != ActionBan && step.Action != ActionPick {
+			return fmt.Errorf("step %d: invalid action %q", i+1, step.Action)
+		}
+		if step.Slot < 0 || step.Slot >= DraftSlots {
+			return fmt.Errorf("step %d: slot %d out of range", i+1, step.Slot)
+		}
+		if used[step] {
+			return fmt.Errorf("step %d: %s %s slot %d is already used", i+1, step.Side, step.Action, step.Slot)
+		}
+		used[step] = true
+		if step.Action == ActionPick {
+			picks[step.Side]++
+		}
+	}
+
+	if picks[TurnBlue] != DraftSlots || picks[TurnRed] != DraftSlots {
+		return fmt.Errorf("each team must have exactly %d picks", DraftSlots)
+	}
+	return nil
+}
+
+// ResolveDraftFormat returns the custom format from the options, a built-in
+// format referenced by name, or the standard/tournament order otherwise.
+func (o DraftOptions) ResolveDraftFormat() (DraftFormat, error) {
+	if o.Format == nil {
+		if o.TournamentBan {
+			return TournamentDraftFormat, nil
+		}
+		return StandardDraftFormat, nil
+	}
+
+	if len(o.Format.Steps) == 0 {
+		format, exists := BuiltinDraftFormats[o.Format.Name]
+		if !exists {
+			return DraftFormat{}, fmt.Errorf("unknown draft format %q", o.Format.Name)
+		}
+		return format, nil
+	}
+
+	if err := o.Format.Validate(); err != nil {
+		return DraftFormat{}, err
+	}
+	return *o.Format, nil
 }
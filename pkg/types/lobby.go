@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
@@ -18,6 +20,17 @@ const (
 	RoleSupport Role = "support"
 )
 
+var Roles = []Role{RoleTop, RoleJungle, RoleMid, RoleBot, RoleSupport}
+
+func (r Role) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type DraftChampionStatus string
 
 const (
@@ -37,20 +50,50 @@ type DraftChampion struct {
 type TeamState struct {
 	Name          string           `json:"name"`
 	Picks         []*DraftChampion `json:"picks"`
-	Bans          []*string        `json:"bans"`
+	Bans          []*DraftChampion `json:"bans"`
 	PreviousPicks []string         `json:"previousPicks"`
 	PreviousBans  []string         `json:"previousBans"`
+	Wins          int              `json:"wins"`
+	Undos         int              `json:"undos"`
+	Pauses        int              `json:"pauses"`
+	TimeBank      int              `json:"timeBank"`
+	Positions     []Role           `json:"positions,omitempty"`
+
+	UnavailableChampionIds []string `json:"unavailableChampionIds"`
+}
+
+func (t *TeamState) Slots(action DraftAction) []*DraftChampion {
+	if action == ActionBan {
+		return t.Bans
+	}
+	return t.Picks
+}
+
+type SideRecord struct {
+	Team      string   `json:"team"`
+	Picks     []string `json:"picks"`
+	Bans      []string `json:"bans"`
+	Positions []Role   `json:"positions,omitempty"`
+}
+
+type GameRecord struct {
+	Game    int            `json:"game"`
+	Blue    SideRecord     `json:"blue"`
+	Red     SideRecord     `json:"red"`
+	Winner  DraftTurn      `json:"winner,omitempty"`
+	History []HistoryEntry `json:"history"`
 }
 
 type DraftPhase string
 
 const (
-	PhaseReady   DraftPhase = "ready"
-	PhaseBan     DraftPhase = "ban"
-	PhasePick    DraftPhase = "pick"
-	PhaseEnd     DraftPhase = "end"
-	PhaseRestart DraftPhase = "restart"
-	PhaseOver    DraftPhase = "over"
+	PhaseReady DraftPhase = "ready"
+	PhaseBan   DraftPhase = "ban"
+	PhasePick  DraftPhase = "pick"
+	PhaseTrade DraftPhase = "trade"
+	PhaseEnd   DraftPhase = "end"
+	PhaseSide  DraftPhase = "side"
+	PhaseOver  DraftPhase = "over"
 )
 
 type DraftTurn string
@@ -62,25 +105,142 @@ const (
 	TurnEnd   DraftTurn = "end"
 )
 
+type FearlessMode string
+
+const (
+	FearlessOff    FearlessMode = "off"
+	FearlessTeam   FearlessMode = "team"
+	FearlessGlobal FearlessMode = "global"
+)
+
+type SideChooser string
+
+const (
+	SideChooserLoser  SideChooser = "loser"
+	SideChooserWinner SideChooser = "winner"
+	SideChooserBlue   SideChooser = "blue"
+	SideChooserRed    SideChooser = "red"
+)
+
+type TimeoutPolicy string
+
+const (
+	TimeoutSkip   TimeoutPolicy = "skip"
+	TimeoutHover  TimeoutPolicy = "hover"
+	TimeoutRandom TimeoutPolicy = "random"
+	TimeoutRole   TimeoutPolicy = "role"
+)
+
 type DraftOptions struct {
-	IsFearless    bool `json:"isFearless"`
-	BanPick       bool `json:"banPick"`
-	KeepBan       bool `json:"keepBan"`
-	TournamentBan bool `json:"tournamentBan"`
-	HasTimer      bool `json:"hasTimer"`
+	IsFearless    bool         `json:"isFearless"`
+	BanPick       bool         `json:"banPick"`
+	KeepBan       bool         `json:"keepBan"`
+	TournamentBan bool         `json:"tournamentBan"`
+	HasTimer      bool         `json:"hasTimer"`
+	Format        *DraftFormat `json:"format,omitempty"`
+	SeriesLength  int          `json:"seriesLength"`
+	UndoLimit     int          `json:"undoLimit"`
+	PauseLimit    int          `json:"pauseLimit"`
+	BanTimer      int          `json:"banTimer"`
+	PickTimer     int          `json:"pickTimer"`
+	TimeBank      int          `json:"timeBank"`
+	TradePhase    bool         `json:"tradePhase"`
+	TradeTimer    int          `json:"tradeTimer"`
+	FearlessMode  FearlessMode `json:"fearlessMode"`
+	CoinToss      bool         `json:"coinToss"`
+	SideChooser   SideChooser  `json:"sideChooser"`
+	SideTimer     int          `json:"sideTimer"`
+
+	BanTimeoutPolicy  TimeoutPolicy `json:"banTimeoutPolicy"`
+	PickTimeoutPolicy TimeoutPolicy `json:"pickTimeoutPolicy"`
+	Seed              *int64        `json:"seed,omitempty"`
+	SpectatorsSeeAll  bool          `json:"spectatorsSeeAll"`
+	SpectatorDelay    int           `json:"spectatorDelay"`
+}
+
+const (
+	DefaultSeriesLength = 5
+	DefaultTurnDuration = 30
+	DefaultTradeTimer   = 60
+	DefaultSideTimer    = 30
+	MaxSpectatorDelay   = 600
+)
+
+func (o DraftOptions) TurnDuration(action DraftAction) int {
+	if action == ActionBan {
+		return o.BanTimer
+	}
+	return o.PickTimer
 }
 
 type DraftState struct {
-	HasTimer            bool         `json:"hasTimer"`
-	Timer               int          `json:"timer"`
-	Phase               DraftPhase   `json:"phase"`
-	Turn                DraftTurn    `json:"turn"`
-	Game                int          `json:"game"`
-	Chat                []string     `json:"chat"`
-	BlueTeam            TeamState    `json:"blueTeam"`
-	RedTeam             TeamState    `json:"redTeam"`
-	Options             DraftOptions `json:"options"`
-	DisabledChampionIds []*string    `json:"disabledChampionIds"`
+	// Timer holds the seconds left on the turn timer when the clock was last
+	// started or stopped. While the clock runs, Deadline is the Unix time in
+	// milliseconds at which the turn timer runs out; the time bank of the team
+	// on turn is consumed after that.
+	HasTimer            bool           `json:"hasTimer"`
+	Timer               int            `json:"timer"`
+	Deadline            int64          `json:"deadline,omitempty"`
+	Phase               DraftPhase     `json:"phase"`
+	Turn                DraftTurn      `json:"turn"`
+	Game                int            `json:"game"`
+	Chat                []ChatMessage  `json:"chat"`
+	BlueTeam            TeamState      `json:"blueTeam"`
+	RedTeam             TeamState      `json:"redTeam"`
+	Options             DraftOptions   `json:"options"`
+	DisabledChampionIds []*string      `json:"disabledChampionIds"`
+	Series              []GameRecord   `json:"series"`
+	PendingUndo         DraftTurn      `json:"pendingUndo,omitempty"`
+	PendingResult       *ResultReport  `json:"pendingResult,omitempty"`
+	Pause               *PauseState    `json:"pause,omitempty"`
+	SideSelection       *SideSelection `json:"sideSelection,omitempty"`
+	History             []HistoryEntry `json:"history"`
+}
+
+type HistoryEntry struct {
+	Step          int         `json:"step"`
+	Side          DraftTurn   `json:"side"`
+	Team          string      `json:"team"`
+	Action        DraftAction `json:"action"`
+	Slot          int         `json:"slot"`
+	ChampionID    string      `json:"championId"`
+	Auto          bool        `json:"auto"`
+	Time          time.Time   `json:"time"`
+	TimeRemaining int         `json:"timeRemaining"`
+	TimeBank      int         `json:"timeBank"`
+}
+
+type SideSelection struct {
+	Chooser  DraftTurn `json:"chooser"`
+	Team     string    `json:"team"`
+	CoinToss bool      `json:"coinToss"`
+	Choice   DraftTurn `json:"choice,omitempty"`
+}
+
+type ChatChannel string
+
+const (
+	ChatAll  ChatChannel = "all"
+	ChatTeam ChatChannel = "team"
+)
+
+type ChatMessage struct {
+	Role    LobbyRole   `json:"role"`
+	Team    string      `json:"team,omitempty"`
+	Name    string      `json:"name"`
+	Channel ChatChannel `json:"channel"`
+	Text    string      `json:"text"`
+	Time    time.Time   `json:"time"`
+}
+
+type ResultReport struct {
+	By     DraftTurn `json:"by"`
+	Winner DraftTurn `json:"winner"`
+}
+
+type PauseState struct {
+	By    DraftTurn `json:"by"`
+	Since time.Time `json:"since"`
 }
 
 type LobbyRole string
@@ -94,18 +254,71 @@ const (
 type EventType string
 
 const (
-	Select  EventType = "SELECT"
-	Hover   EventType = "HOVER"
-	Message EventType = "MESSAGE"
-	Start   EventType = "START"
-	Timeout EventType = "TIMEOUT"
+	Select        EventType = "SELECT"
+	Hover         EventType = "HOVER"
+	Message       EventType = "MESSAGE"
+	Start         EventType = "START"
+	Timeout       EventType = "TIMEOUT"
+	Result        EventType = "RESULT"
+	ResultAccept  EventType = "RESULT_ACCEPT"
+	ResultDecline EventType = "RESULT_DECLINE"
+	UndoRequest   EventType = "UNDO_REQUEST"
+	UndoAccept    EventType = "UNDO_ACCEPT"
+	UndoDecline   EventType = "UNDO_DECLINE"
+	Pause         EventType = "PAUSE"
+	Resume        EventType = "RESUME"
+	SideChoice    EventType = "SIDE"
+	Trade         EventType = "TRADE"
+	Positions     EventType = "POSITIONS"
+	Resync        EventType = "RESYNC"
 )
 
 type Event struct {
-	User    DraftTurn `json:"user"`
-	Type    EventType `json:"type"`
-	Payload Payload   `json:"payload"`
-	Flag    bool      `json:"flag"`
+	User      DraftTurn   `json:"user"`
+	Type      EventType   `json:"type"`
+	Payload   Payload     `json:"payload"`
+	Flag      bool        `json:"flag"`
+	Side      DraftTurn   `json:"side,omitempty"`
+	Order     []int       `json:"order,omitempty"`
+	Positions []Role      `json:"positions,omitempty"`
+	Version   int64       `json:"version,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Channel   ChatChannel `json:"channel,omitempty"`
+	Sender    *User       `json:"-"`
+}
+
+type ServerMessageType string
+
+const (
+	MessageError    ServerMessageType = "ERROR"
+	MessageSnapshot ServerMessageType = "SNAPSHOT"
+	MessagePatch    ServerMessageType = "PATCH"
+)
+
+type ErrorMessage struct {
+	Type   ServerMessageType `json:"type"`
+	Reason string            `json:"reason"`
+}
+
+type SnapshotMessage struct {
+	Type       ServerMessageType `json:"type"`
+	Version    int64             `json:"version"`
+	ServerTime int64             `json:"serverTime"`
+	State      json.RawMessage   `json:"state"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type PatchMessage struct {
+	Type        ServerMessageType `json:"type"`
+	Version     int64             `json:"version"`
+	BaseVersion int64             `json:"baseVersion"`
+	ServerTime  int64             `json:"serverTime"`
+	Ops         []PatchOperation  `json:"ops"`
 }
 
 type ChampionStatus string
@@ -124,11 +337,81 @@ type Payload struct {
 	Status ChampionStatus `json:"status"`
 }
 
+const (
+	userSendBuffer   = 64
+	userWriteTimeout = 10 * time.Second
+)
+
 type User struct {
 	ID       string
 	Conn     *websocket.Conn
 	Role     LobbyRole
 	Username string
+
+	outbox    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewUser starts the writer of the connection. Messages are written by a single
+// goroutine per user, so broadcasts never wait on a slow client.
+func NewUser(id string, conn *websocket.Conn, role LobbyRole, username string) *User {
+	user := &User{
+		ID:       id,
+		Conn:     conn,
+		Role:     role,
+		Username: username,
+		outbox:   make(chan []byte, userSendBuffer),
+		done:     make(chan struct{}),
+	}
+	go user.writeMessages()
+	return user
+}
+
+// Send queues a text message for the user without blocking. A user that falls
+// too far behind is disconnected instead of holding up the lobby.
+func (u *User) Send(message []byte) error {
+	select {
+	case <-u.done:
+		return fmt.Errorf("connection closed")
+	default:
+	}
+
+	select {
+	case u.outbox <- message:
+		return nil
+	default:
+		u.Close()
+		return fmt.Errorf("send buffer full")
+	}
+}
+
+func (u *User) Close() {
+	u.closeOnce.Do(func() {
+		close(u.done)
+		u.Conn.Close()
+	})
+}
+
+func (u *User) writeMessages() {
+	for {
+		select {
+		case message := <-u.outbox:
+			u.Conn.SetWriteDeadline(time.Now().Add(userWriteTimeout))
+			if err := u.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				u.Close()
+				return
+			}
+		case <-u.done:
+			return
+		}
+	}
+}
+
+type BroadcasterInterface interface {
+	Broadcast(lobby *Lobby)
+	Join(user *User)
+	Resync(user *User, version int64)
 }
 
 type Lobby struct {
@@ -140,14 +423,21 @@ type Lobby struct {
 	Mutex            sync.RWMutex
 	DraftState       DraftState
 	DraftService     DraftServiceInterface
+	Broadcaster      BroadcasterInterface
 	Champions        []*DraftChampion
 	LastActivityTime time.Time
+	Seed             int64
 }
 
 func NewLobby(options DraftOptions, blueTeamName string, redTeamName string, champions []*DraftChampion, disabledChampionIds []*string) *Lobby {
+	seed := time.Now().UnixNano()
+	if options.Seed != nil {
+		seed = *options.Seed
+	}
+
 	var timer int
-	if options.HasTimer {
-		timer = 30
+	if options.HasTimer && options.Format != nil && len(options.Format.Steps) > 0 {
+		timer = options.TurnDuration(options.Format.Steps[0].Action)
 	} else {
 		timer = 0
 	}
@@ -163,26 +453,33 @@ func NewLobby(options DraftOptions, blueTeamName string, redTeamName string, cha
 			Phase:    PhaseReady,
 			Turn:     TurnStart,
 			Game:     1,
-			Chat:     []string{},
+			Chat:     []ChatMessage{},
 			BlueTeam: TeamState{
-				Name:          blueTeamName,
-				Picks:         make([]*DraftChampion, 5),
-				Bans:          make([]*string, 5),
-				PreviousPicks: []string{},
-				PreviousBans:  []string{},
+				Name:                   blueTeamName,
+				Picks:                  make([]*DraftChampion, 5),
+				Bans:                   make([]*DraftChampion, 5),
+				PreviousPicks:          []string{},
+				PreviousBans:           []string{},
+				TimeBank:               options.TimeBank,
+				UnavailableChampionIds: []string{},
 			},
 			RedTeam: TeamState{
-				Name:          redTeamName,
-				Picks:         make([]*DraftChampion, 5),
-				Bans:          make([]*string, 5),
-				PreviousPicks: []string{},
-				PreviousBans:  []string{},
+				Name:                   redTeamName,
+				Picks:                  make([]*DraftChampion, 5),
+				Bans:                   make([]*DraftChampion, 5),
+				PreviousPicks:          []string{},
+				PreviousBans:           []string{},
+				TimeBank:               options.TimeBank,
+				UnavailableChampionIds: []string{},
 			},
 			Options:             options,
 			DisabledChampionIds: disabledChampionIds,
+			Series:              []GameRecord{},
+			History:             []HistoryEntry{},
 		},
 		Champions:        champions,
 		LastActivityTime: time.Now(),
+		Seed:             seed,
 	}
 }
 